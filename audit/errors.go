@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/pkg/errors"
+
+// List of audit related errors
+var (
+	ErrInvalidListQuery = errors.New("invalid list query")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
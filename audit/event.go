@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventID is an audit event id
+type EventID string
+
+// NilEventID is a nil audit event id
+var NilEventID = EventID("")
+
+// NewEventID returns a new audit event id
+func NewEventID() EventID {
+	return EventID(uuid.NewString())
+}
+
+// Action is an audited admin action
+type Action string
+
+// List of audited admin actions
+const (
+	ActionGenerateAuthKey Action = "authkey.generate"
+	ActionListAuthKeys    Action = "authkey.list"
+	ActionRevokeAuthKey   Action = "authkey.revoke"
+
+	ActionGenerateTokens  Action = "token.generate"
+	ActionListTokens      Action = "token.list"
+	ActionSummarizeTokens Action = "token.summarize"
+	ActionGetToken        Action = "token.get"
+	ActionDisableToken    Action = "token.disable"
+	ActionEnableToken     Action = "token.enable"
+	ActionExtendToken     Action = "token.extend"
+	ActionRevokeToken     Action = "token.revoke"
+
+	ActionCreateCampaign  Action = "campaign.create"
+	ActionListCampaigns   Action = "campaign.list"
+	ActionGetCampaign     Action = "campaign.get"
+	ActionUpdateCampaign  Action = "campaign.update"
+	ActionDisableCampaign Action = "campaign.disable"
+	ActionEnableCampaign  Action = "campaign.enable"
+	ActionDeleteCampaign  Action = "campaign.delete"
+
+	ActionListLockouts Action = "lockout.list"
+	ActionClearLockout Action = "lockout.clear"
+
+	ActionListEvents Action = "audit.list"
+)
+
+// Event is an audit event of an admin action
+type Event struct {
+	// ID is the event id
+	ID EventID `json:"id"`
+	// Actor is the id of the authenticated caller, e.g. the auth key id
+	Actor string `json:"actor"`
+	// AuthMethod is the authentication method of the caller
+	AuthMethod string `json:"authMethod"`
+	// Action is the admin action
+	Action Action `json:"action"`
+	// Target is the id of the affected resource, e.g. the token,
+	// the ids of a batch of tokens are comma separated
+	Target string `json:"target"`
+	// Status is the response status code
+	Status int `json:"status"`
+	// RequestID is the request id
+	RequestID string `json:"requestId"`
+	// IPAddress is the client ip address
+	IPAddress string `json:"ipAddress"`
+	// CreatedAt is the event timestamp
+	CreatedAt *time.Time `json:"createdAt"`
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// listEventsResponse is the list events response
+type listEventsResponse struct {
+	Events     []*Event `json:"events"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// NewListEventsHandler returns a handler which is used for listing audit events
+func NewListEventsHandler(auditSvc Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query, err := parseListQuery(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		page, err := auditSvc.ListEvents(c.Request().Context(), query)
+		if err != nil {
+			if errors.Is(err, ErrInvalidListQuery) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			return errors.Wrap(err, "list events")
+		}
+
+		resp := listEventsResponse{Events: page.Events}
+		if page.Next != nil {
+			resp.NextCursor = page.Next.String()
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// parseListQuery parses the list events query params
+func parseListQuery(c echo.Context) (ListQuery, error) {
+	query := ListQuery{
+		Actor:  c.QueryParam("actor"),
+		Action: Action(c.QueryParam("action")),
+		Target: c.QueryParam("target"),
+	}
+
+	var err error
+	if s := c.QueryParam("limit"); s != "" {
+		query.Limit, err = strconv.Atoi(s)
+		if err != nil {
+			return query, errors.New("invalid limit")
+		}
+	}
+
+	if s := c.QueryParam("cursor"); s != "" {
+		query.After, err = ParseCursor(s)
+		if err != nil {
+			return query, err
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/stevenferrer/invitesvc/authn"
+)
+
+// targetContextKey is the echo context key of the event target
+const targetContextKey = "audit.target"
+
+// NewMiddleware returns a middleware that records an audit event of the
+// action after the request is handled, it must be used after the auth
+// middleware. The event target defaults to the first path param.
+func NewMiddleware(auditSvc Service, action Action) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			event := &Event{
+				Action:    action,
+				Target:    target(c),
+				Status:    status(c, err),
+				RequestID: requestID(c),
+				IPAddress: c.RealIP(),
+			}
+
+			if principal := authn.PrincipalFromContext(c); principal != nil {
+				event.Actor = principal.ID
+				event.AuthMethod = principal.Method
+			}
+
+			// the response is already handled, only log the failure
+			recErr := auditSvc.RecordEvent(c.Request().Context(), event)
+			if recErr != nil {
+				c.Logger().Errorf("record audit event: %v", recErr)
+			}
+
+			return err
+		}
+	}
+}
+
+// SetTarget sets the event target of the request, e.g.
+// when the target is created by the request
+func SetTarget(c echo.Context, target string) {
+	c.Set(targetContextKey, target)
+}
+
+// target returns the event target of the request
+func target(c echo.Context) string {
+	if target, ok := c.Get(targetContextKey).(string); ok {
+		return target
+	}
+
+	if keyID := authn.GeneratedKeyIDFromContext(c); keyID != "" {
+		return string(keyID)
+	}
+
+	if values := c.ParamValues(); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// status returns the response status code of the request
+func status(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// requestID returns the request id set by the request id middleware
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultListLimit is the default number of events per page
+	DefaultListLimit = 50
+	// MaxListLimit is the max number of events per page
+	MaxListLimit = 1000
+)
+
+// ListQuery is the query for listing events, events
+// are sorted by creation timestamp, newest first
+type ListQuery struct {
+	// Actor filters the events by actor
+	Actor string
+	// Action filters the events by action
+	Action Action
+	// Target filters the events by target
+	Target string
+	// Limit is the max number of events, zero means no limit
+	Limit int
+	// After is the cursor of the last event in the previous page
+	After *Cursor
+}
+
+// Page is a page of events
+type Page struct {
+	// Events is the list of events
+	Events []*Event
+	// Next is the cursor for the next page, nil on the last page
+	Next *Cursor
+}
+
+// Cursor points to an event in the event list
+type Cursor struct {
+	CreatedAt time.Time
+	ID        EventID
+}
+
+// cursorSep is the cursor field separator
+const cursorSep = "|"
+
+// String returns the encoded cursor
+func (c *Cursor) String() string {
+	s := c.CreatedAt.Format(time.RFC3339Nano) + cursorSep + string(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// ParseCursor parses an encoded cursor
+func ParseCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(b), cursorSep, 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: createdAt, ID: EventID(parts[1])}, nil
+}
+
+// cursorOf returns the cursor of an event
+func cursorOf(event *Event) *Cursor {
+	return &Cursor{CreatedAt: *event.CreatedAt, ID: event.ID}
+}
@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+// Repository is an audit event repository
+type Repository interface {
+	// CreateEvent creates an audit event
+	CreateEvent(context.Context, *Event) error
+	// ListEvents retrieves list of events matching the query from db
+	ListEvents(context.Context, ListQuery) ([]*Event, error)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Service is an audit service
+type Service interface {
+	// RecordEvent records an audit event
+	RecordEvent(context.Context, *Event) error
+	// ListEvents retrieves a page of events, newest first
+	ListEvents(context.Context, ListQuery) (*Page, error)
+}
+
+// auditService implements audit service
+type auditService struct {
+	repo Repository
+}
+
+var _ Service = (*auditService)(nil)
+
+// NewService returns a new audit service
+func NewService(repo Repository) Service {
+	return &auditService{repo: repo}
+}
+
+func (svc *auditService) RecordEvent(ctx context.Context, event *Event) error {
+	if event.ID == NilEventID {
+		event.ID = NewEventID()
+	}
+
+	err := svc.repo.CreateEvent(ctx, event)
+	return errors.Wrap(err, "create event")
+}
+
+func (svc *auditService) ListEvents(ctx context.Context, query ListQuery) (*Page, error) {
+	if query.Limit < 0 || query.Limit > MaxListLimit {
+		return nil, ErrInvalidListQuery
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+
+	// fetch one more to know if there's a next page
+	query.Limit = limit + 1
+	events, err := svc.repo.ListEvents(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "list events")
+	}
+
+	page := &Page{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.Next = cursorOf(page.Events[limit-1])
+	}
+
+	return page, nil
+}
@@ -0,0 +1,93 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/audit"
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestService(t *testing.T) {
+	storagetest.Run(t, testService)
+}
+
+func testService(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	auditRepo := repos.Audit
+	auditSvc := audit.NewService(auditRepo)
+
+	ctx := context.TODO()
+	actions := []audit.Action{
+		audit.ActionGenerateTokens,
+		audit.ActionDisableToken,
+		audit.ActionGenerateTokens,
+		audit.ActionListTokens,
+		audit.ActionGenerateAuthKey,
+	}
+	for _, action := range actions {
+		event := &audit.Event{
+			Actor:      "abcd1234",
+			AuthMethod: "authkey",
+			Action:     action,
+			Status:     200,
+		}
+		err := auditSvc.RecordEvent(ctx, event)
+		require.NoError(t, err)
+		assert.NotEmpty(t, event.ID)
+		assert.NotNil(t, event.CreatedAt)
+	}
+
+	t.Run("paginate events", func(t *testing.T) {
+		var (
+			events []*audit.Event
+			query  = audit.ListQuery{Limit: 2}
+		)
+		for {
+			page, err := auditSvc.ListEvents(ctx, query)
+			require.NoError(t, err)
+			events = append(events, page.Events...)
+
+			if page.Next == nil {
+				break
+			}
+
+			// cursors survive encoding
+			query.After, err = audit.ParseCursor(page.Next.String())
+			require.NoError(t, err)
+		}
+
+		require.Len(t, events, len(actions))
+		// newest first
+		for i, event := range events {
+			assert.Equal(t, actions[len(actions)-1-i], event.Action)
+		}
+	})
+
+	t.Run("filter events", func(t *testing.T) {
+		page, err := auditSvc.ListEvents(ctx, audit.ListQuery{
+			Action: audit.ActionGenerateTokens,
+		})
+		require.NoError(t, err)
+		assert.Len(t, page.Events, 2)
+		assert.Nil(t, page.Next)
+
+		page, err = auditSvc.ListEvents(ctx, audit.ListQuery{Actor: "other"})
+		require.NoError(t, err)
+		assert.Len(t, page.Events, 0)
+	})
+
+	t.Run("invalid list query", func(t *testing.T) {
+		_, err := auditSvc.ListEvents(ctx, audit.ListQuery{Limit: -1})
+		assert.ErrorIs(t, err, audit.ErrInvalidListQuery)
+
+		_, err = auditSvc.ListEvents(ctx, audit.ListQuery{Limit: audit.MaxListLimit + 1})
+		assert.ErrorIs(t, err, audit.ErrInvalidListQuery)
+
+		_, err = audit.ParseCursor("invalid")
+		assert.ErrorIs(t, err, audit.ErrInvalidCursor)
+	})
+}
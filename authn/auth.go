@@ -1,18 +1,120 @@
 package authn
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 )
 
-// Auth is an auth record
+const (
+	// keyIDLen is the len of the auth key id
+	keyIDLen = 8
+	// saltLen is the number of random bytes in a salt
+	saltLen = 16
+)
+
+// Auth is an auth record, only the hash of the auth key is stored
 type Auth struct {
-	Auth      AuthKey
+	// ID is the auth key id
+	ID KeyID
+	// Hash is the salted hash of the auth key
+	Hash string
+	// Salt is the random salt used for hashing the auth key
+	Salt string
+	// Name is the auth key name
+	Name string
+	// Owner is the auth key owner
+	Owner string
+	// Scopes is the list of permissions granted to the auth key
+	Scopes []Scope
+	// SigningKey is the sealed request signing key derived from the
+	// auth key, empty if request signing is not enabled for the auth key
+	SigningKey string
+	// ExpiresAt is the expiration timestamp, nil if never expires
+	ExpiresAt *time.Time
+	// RevokedAt is the revoke timestamp, nil if not revoked
+	RevokedAt *time.Time
+	// LastUsedAt is the timestamp when the auth key was last used
+	LastUsedAt *time.Time
+	// CreatedAt is the created at timestamp
 	CreatedAt *time.Time
 }
 
+// NewAuth returns a new auth record for the auth key
+func NewAuth(authKey AuthKey) (*Auth, error) {
+	b := make([]byte, saltLen)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate salt")
+	}
+
+	salt := hex.EncodeToString(b)
+	return &Auth{
+		ID:   authKey.ID(),
+		Hash: HashAuthKey(authKey, salt),
+		Salt: salt,
+	}, nil
+}
+
+// Matches returns true if the auth key matches the stored hash
+func (a *Auth) Matches(authKey AuthKey) bool {
+	hash := HashAuthKey(authKey, a.Salt)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(a.Hash)) == 1
+}
+
+// HasScope returns true if the scope is granted to the auth key
+func (a *Auth) HasScope(scope Scope) bool {
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Revoked returns true if the auth key was revoked
+func (a *Auth) Revoked() bool {
+	return a.RevokedAt != nil
+}
+
+// Expired returns true if the auth key is expired
+func (a *Auth) Expired() bool {
+	// auth key never expires
+	if a.ExpiresAt == nil {
+		return false
+	}
+
+	return time.Now().After(*a.ExpiresAt)
+}
+
+// Validate returns an error if the auth key can't be used
+func (a *Auth) Validate() error {
+	if a.Revoked() {
+		return ErrAuthKeyRevoked
+	}
+
+	if a.Expired() {
+		return ErrAuthKeyExpired
+	}
+
+	return nil
+}
+
+// KeyID is the public id of an auth key, which is the
+// prefix of the auth key and is used for looking up the hash
+type KeyID string
+
+// NilKeyID is a nil auth key id
+var NilKeyID = KeyID("")
+
 // AuthKey is an API auth keys
 type AuthKey string
 
@@ -24,3 +126,29 @@ func NewAuthKey() AuthKey {
 	s := strings.ReplaceAll(uuid.NewString(), "-", "")
 	return AuthKey(s)
 }
+
+// ID returns the auth key id
+func (k AuthKey) ID() KeyID {
+	if len(k) < keyIDLen {
+		return NilKeyID
+	}
+
+	return KeyID(k[:keyIDLen])
+}
+
+// HashAuthKey returns the hex encoded sha256 hash of the salted auth key
+func HashAuthKey(authKey AuthKey, salt string) string {
+	sum := sha256.Sum256([]byte(salt + string(authKey)))
+	return hex.EncodeToString(sum[:])
+}
+
+// signingKeyContext is the context used when deriving request signing keys
+const signingKeyContext = "invitesvc request signing"
+
+// DeriveSigningKey returns the hex encoded request signing key
+// derived from the auth key, HMAC-SHA256(authKey, "invitesvc request signing")
+func DeriveSigningKey(authKey AuthKey) string {
+	mac := hmac.New(sha256.New, []byte(authKey))
+	mac.Write([]byte(signingKeyContext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
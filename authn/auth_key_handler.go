@@ -2,20 +2,142 @@ package authn
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 )
 
+// keyIDContextKey is the echo context key of the generated key id
+const keyIDContextKey = "authn.keyId"
+
+// genAuthKeyRequest is the request for generating auth key
+type genAuthKeyRequest struct {
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	Scopes    []Scope    `json:"scopes"`
+	TTL       string     `json:"ttl"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Signing   bool       `json:"signing"`
+}
+
 // NewAuthKey returns an auth key handler which is used for generating auth keys
 func NewAuthKeyHandler(authSvc Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		authKey, err := authSvc.GenerateAuthKey(c.Request().Context())
+		var req genAuthKeyRequest
+		err := c.Bind(&req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			ttl, err = time.ParseDuration(req.TTL)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid ttl")
+			}
+		}
+
+		authKey, err := authSvc.GenerateAuthKey(c.Request().Context(), GenerateParams{
+			Name:      req.Name,
+			Owner:     req.Owner,
+			Scopes:    req.Scopes,
+			TTL:       ttl,
+			ExpiresAt: req.ExpiresAt,
+			Signing:   req.Signing,
+		})
 		if err != nil {
+			if errors.Is(err, ErrInvalidExpiration) ||
+				errors.Is(err, ErrInvalidScopes) ||
+				errors.Is(err, ErrSigningDisabled) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
 			return err
 		}
 
+		c.Set(keyIDContextKey, authKey.ID())
+
+		// the auth key is only shown once, make sure it's not cached
+		c.Response().Header().Set("Cache-Control", "no-store")
 		return c.JSON(http.StatusCreated, echo.Map{
 			"authKey": authKey,
+			"keyId":   authKey.ID(),
+		})
+	}
+}
+
+// GeneratedKeyIDFromContext returns the id of the auth key
+// generated by the request, or empty if there's none
+func GeneratedKeyIDFromContext(c echo.Context) KeyID {
+	keyID, _ := c.Get(keyIDContextKey).(KeyID)
+	return keyID
+}
+
+// authKeyResponse is an auth key response, it never includes the auth key
+type authKeyResponse struct {
+	KeyID      KeyID      `json:"keyId"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Scopes     []Scope    `json:"scopes"`
+	Active     bool       `json:"active"`
+	Signing    bool       `json:"signing"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
+
+// NewListAuthKeysHandler returns a handler which is used for listing auth keys
+func NewListAuthKeysHandler(authSvc Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auths, err := authSvc.ListAuthKeys(c.Request().Context())
+		if err != nil {
+			return errors.Wrap(err, "list auth keys")
+		}
+
+		resp := make([]authKeyResponse, 0, len(auths))
+		for _, auth := range auths {
+			resp = append(resp, authKeyResponse{
+				KeyID:      auth.ID,
+				Name:       auth.Name,
+				Owner:      auth.Owner,
+				Scopes:     auth.Scopes,
+				Active:     auth.Validate() == nil,
+				Signing:    auth.SigningKey != "",
+				ExpiresAt:  auth.ExpiresAt,
+				RevokedAt:  auth.RevokedAt,
+				LastUsedAt: auth.LastUsedAt,
+				CreatedAt:  auth.CreatedAt,
+			})
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"authKeys": resp,
+		})
+	}
+}
+
+// NewRevokeAuthKeyHandler returns a handler which is used for
+// revoking auth keys, the auth key id is read from the keyId param
+func NewRevokeAuthKeyHandler(authSvc Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		keyID := KeyID(c.Param("keyId"))
+		err := authSvc.RevokeAuthKey(c.Request().Context(), keyID)
+		if err != nil {
+			if errors.Is(err, ErrAuthKeyNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "auth key not found")
+			}
+
+			if errors.Is(err, ErrAuthKeyRevoked) {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+
+			return errors.Wrap(err, "revoke auth key")
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"message": "auth key successfully revoked.",
 		})
 	}
 }
@@ -4,41 +4,122 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stevenferrer/invitesvc/authn"
-	"github.com/stevenferrer/invitesvc/postgres"
-	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/storagetest"
 )
 
 func TestAuthKeyHandler(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	// migrate db
-	postgres.MustMigrate(db)
+	storagetest.Run(t, testAuthKeyHandler)
+}
 
-	authRepo := postgres.NewAuthRepository(db)
-	authSvc := authn.NewAuthService(authRepo)
+func testAuthKeyHandler(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	authRepo := repos.Auth
+	authSvc := authn.NewAuthService(authRepo, authn.WithSigningSecret(signingSecret))
 
 	authKeyHandler := authn.NewAuthKeyHandler(authSvc)
 	e := echo.New()
 	e.POST("/", authKeyHandler)
+	e.GET("/", authn.NewListAuthKeysHandler(authSvc))
+	e.PUT("/:keyId/revoke", authn.NewRevokeAuthKeyHandler(authSvc))
 
 	// generate token
-	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	body := strings.NewReader(`{"name": "ci", "owner": "ops@example.com", "scopes": ["tokens:read"], "ttl": "720h", "signing": true}`)
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rr := httptest.NewRecorder()
 	e.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusCreated, rr.Code)
 
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
 	var resp = struct {
 		AuthKey string `json:"authKey"`
+		KeyID   string `json:"keyId"`
 	}{}
 	err := json.NewDecoder(rr.Body).Decode(&resp)
 	require.NoError(t, err)
 	assert.NotEmpty(t, resp.AuthKey)
+	assert.Equal(t, string(authn.AuthKey(resp.AuthKey).ID()), resp.KeyID)
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		body := strings.NewReader(`{"scopes": ["tokens:read"], "ttl": "-1h"}`)
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid scopes", func(t *testing.T) {
+		body := strings.NewReader(`{"scopes": ["tokens:delete"]}`)
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("list auth keys", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), resp.AuthKey)
+
+		var listResp = struct {
+			AuthKeys []struct {
+				KeyID     string     `json:"keyId"`
+				Name      string     `json:"name"`
+				Owner     string     `json:"owner"`
+				Scopes    []string   `json:"scopes"`
+				Active    bool       `json:"active"`
+				Signing   bool       `json:"signing"`
+				ExpiresAt *time.Time `json:"expiresAt"`
+			} `json:"authKeys"`
+		}{}
+		err := json.NewDecoder(rr.Body).Decode(&listResp)
+		require.NoError(t, err)
+		require.Len(t, listResp.AuthKeys, 1)
+		assert.Equal(t, resp.KeyID, listResp.AuthKeys[0].KeyID)
+		assert.Equal(t, "ci", listResp.AuthKeys[0].Name)
+		assert.Equal(t, "ops@example.com", listResp.AuthKeys[0].Owner)
+		assert.Equal(t, []string{"tokens:read"}, listResp.AuthKeys[0].Scopes)
+		assert.True(t, listResp.AuthKeys[0].Active)
+		assert.True(t, listResp.AuthKeys[0].Signing)
+		assert.NotNil(t, listResp.AuthKeys[0].ExpiresAt)
+	})
+
+	t.Run("revoke auth key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/"+resp.KeyID+"/revoke", nil)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// already revoked
+		req = httptest.NewRequest(http.MethodPut, "/"+resp.KeyID+"/revoke", nil)
+		rr = httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		// not found
+		req = httptest.NewRequest(http.MethodPut, "/"+string(authn.NewAuthKey().ID())+"/revoke", nil)
+		rr = httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
 }
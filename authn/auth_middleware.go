@@ -1,6 +1,8 @@
 package authn
 
 import (
+	"net/http"
+
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 )
@@ -8,26 +10,80 @@ import (
 // AuthKeyHeader is the auth key header
 const AuthKeyHeader = "X-AUTH-KEY"
 
-// NewAuthMiddleware is an authentication middleware
+// principalContextKey is the echo context key of the principal
+const principalContextKey = "authn.principal"
+
+// NewAuthMiddleware is an authentication middleware that only
+// accepts auth keys and requests signed with auth keys
 func NewAuthMiddleware(authSvc Service) echo.MiddlewareFunc {
+	return NewMiddleware(NewAuthKeyAuthenticator(authSvc),
+		NewSignatureAuthenticator(authSvc))
+}
+
+// NewMiddleware is an authentication middleware, the authenticators
+// are tried in order until one finds credentials in the request
+func NewMiddleware(authenticators ...Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			authKey := c.Request().Header.Get(AuthKeyHeader)
-			if authKey == "" {
-				return echo.ErrUnauthorized
-			}
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(c)
+				if err != nil {
+					if errors.Is(err, ErrNoCredentials) {
+						continue
+					}
+
+					if isUnauthorizedErr(err) {
+						return echo.ErrUnauthorized
+					}
+
+					return errors.Wrap(err, "authenticate")
+				}
 
-			// check auth key exists
-			exists, err := authSvc.AuthKeyExists(c.Request().Context(), AuthKey(authKey))
-			if err != nil {
-				return errors.Wrap(err, "check auth key exists")
+				c.Set(principalContextKey, principal)
+				return next(c)
 			}
 
-			if !exists {
+			// no credentials
+			return echo.ErrUnauthorized
+		}
+	}
+}
+
+// NewScopeMiddleware returns a middleware that only allows requests
+// authenticated with a principal that has the scope, it must be used
+// after the auth middleware
+func NewScopeMiddleware(scope Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := PrincipalFromContext(c)
+			if principal == nil {
 				return echo.ErrUnauthorized
 			}
 
+			if !principal.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden,
+					"missing scope "+string(scope))
+			}
+
 			return next(c)
 		}
 	}
 }
+
+// PrincipalFromContext returns the principal of the
+// authenticated request, nil if not authenticated
+func PrincipalFromContext(c echo.Context) *Principal {
+	principal, _ := c.Get(principalContextKey).(*Principal)
+	return principal
+}
+
+// isUnauthorizedErr returns true if err is caused by invalid credentials
+func isUnauthorizedErr(err error) bool {
+	return errors.Is(err, ErrInvalidAuthKey) ||
+		errors.Is(err, ErrAuthKeyRevoked) ||
+		errors.Is(err, ErrAuthKeyExpired) ||
+		errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrInvalidSignature) ||
+		errors.Is(err, ErrNonceReused) ||
+		errors.Is(err, ErrInvalidClientCert)
+}
@@ -2,8 +2,10 @@ package authn_test
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -11,22 +13,22 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/stevenferrer/invitesvc/authn"
-	"github.com/stevenferrer/invitesvc/postgres"
-	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/storagetest"
 )
 
 func TestAuthMiddleware(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	// migrate db
-	postgres.MustMigrate(db)
+	storagetest.Run(t, testAuthMiddleware)
+}
 
-	authRepo := postgres.NewAuthRepository(db)
-	authSvc := authn.NewAuthService(authRepo)
+func testAuthMiddleware(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	authRepo := repos.Auth
+	authSvc := authn.NewAuthService(authRepo, authn.WithSigningSecret(signingSecret))
 
 	ctx := context.Background()
-	authKey, err := authSvc.GenerateAuthKey(ctx)
+	authKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+		Scopes: authn.Scopes,
+	})
 	require.NoError(t, err)
 
 	authMiddleware := authn.NewAuthMiddleware(authSvc)
@@ -37,6 +39,9 @@ func TestAuthMiddleware(t *testing.T) {
 	e.GET("/", func(c echo.Context) error {
 		return c.NoContent(http.StatusOK)
 	})
+	e.GET("/keys", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, authn.NewScopeMiddleware(authn.ScopeKeysAdmin))
 
 	t.Run("authorized", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -45,6 +50,81 @@ func TestAuthMiddleware(t *testing.T) {
 		e.ServeHTTP(rr, req)
 		assert.Equal(t, http.StatusOK, rr.Code)
 
+		// verify auth key usage is tracked
+		auth, err := authRepo.GetAuthKey(ctx, authKey.ID())
+		require.NoError(t, err)
+		assert.NotNil(t, auth.LastUsedAt)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		readKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: []authn.Scope{authn.ScopeTokensRead},
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/keys", nil)
+		req.Header.Add(authn.AuthKeyHeader, string(readKey))
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/keys", nil)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		rr = httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("signed request", func(t *testing.T) {
+		signingKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes:  []authn.Scope{authn.ScopeKeysAdmin},
+			Signing: true,
+		})
+		require.NoError(t, err)
+
+		e.POST("/keys", func(c echo.Context) error {
+			// the body can still be read by the handler
+			b, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return err
+			}
+			return c.String(http.StatusOK, string(b))
+		}, authn.NewScopeMiddleware(authn.ScopeKeysAdmin))
+
+		req := httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"ci"}`))
+		err = authn.SignRequest(req, signingKey)
+		require.NoError(t, err)
+		assert.Empty(t, req.Header.Get(authn.AuthKeyHeader))
+
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"name":"ci"}`, rr.Body.String())
+
+		// replayed request
+		replay := httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"ci"}`))
+		replay.Header = req.Header.Clone()
+		rr = httptest.NewRecorder()
+		e.ServeHTTP(rr, replay)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		// tampered body
+		req = httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"ci"}`))
+		err = authn.SignRequest(req, signingKey)
+		require.NoError(t, err)
+		tampered := httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"evil"}`))
+		tampered.Header = req.Header.Clone()
+		rr = httptest.NewRecorder()
+		e.ServeHTTP(rr, tampered)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		// auth key without request signing
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		err = authn.SignRequest(req, authKey)
+		require.NoError(t, err)
+		rr = httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
 	t.Run("un-authorized", func(t *testing.T) {
@@ -58,6 +138,16 @@ func TestAuthMiddleware(t *testing.T) {
 		rr = httptest.NewRecorder()
 		e.ServeHTTP(rr, req)
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		// revoked auth key
+		err = authSvc.RevokeAuthKey(ctx, authKey.ID())
+		require.NoError(t, err)
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		rr = httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
 }
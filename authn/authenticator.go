@@ -0,0 +1,80 @@
+package authn
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// List of authentication methods
+const (
+	// MethodAuthKey is the auth key authentication method
+	MethodAuthKey = "authkey"
+	// MethodJWT is the JWT bearer token authentication method
+	MethodJWT = "jwt"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// ID is the caller id, e.g. the auth key id or the token subject
+	ID string
+	// Method is the authentication method
+	Method string
+	// Scopes is the list of permissions granted to the caller
+	Scopes []Scope
+}
+
+// HasScope returns true if the scope is granted to the principal
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticator authenticates requests
+type Authenticator interface {
+	// Authenticate returns the principal of the request. ErrNoCredentials
+	// is returned when the request has no credentials for the authenticator.
+	Authenticate(echo.Context) (*Principal, error)
+}
+
+// authKeyAuthenticator authenticates requests with auth keys
+type authKeyAuthenticator struct {
+	authSvc Service
+}
+
+// NewAuthKeyAuthenticator returns an authenticator that
+// authenticates requests with the auth key header
+func NewAuthKeyAuthenticator(authSvc Service) Authenticator {
+	return &authKeyAuthenticator{authSvc: authSvc}
+}
+
+// Authenticate verifies the auth key and tracks its usage
+func (a *authKeyAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	authKey := c.Request().Header.Get(AuthKeyHeader)
+	if authKey == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// verify auth key
+	ctx := c.Request().Context()
+	auth, err := a.authSvc.VerifyAuthKey(ctx, AuthKey(authKey))
+	if err != nil {
+		return nil, err
+	}
+
+	// track auth key usage
+	err = a.authSvc.TouchAuthKey(ctx, auth.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "touch auth key")
+	}
+
+	return &Principal{
+		ID:     string(auth.ID),
+		Method: MethodAuthKey,
+		Scopes: auth.Scopes,
+	}, nil
+}
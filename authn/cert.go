@@ -0,0 +1,52 @@
+package authn
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// MethodClientCert is the client certificate authentication method
+const MethodClientCert = "clientcert"
+
+// clientCertAuthenticator authenticates requests with TLS client certificates
+type clientCertAuthenticator struct {
+	scopeMap map[string][]Scope
+}
+
+// NewClientCertAuthenticator returns an authenticator that authenticates
+// requests with verified TLS client certificates, the scopes are mapped
+// from the certificate subject common name
+func NewClientCertAuthenticator(scopeMap map[string][]Scope) Authenticator {
+	return &clientCertAuthenticator{scopeMap: scopeMap}
+}
+
+// Authenticate maps the verified client certificate to a principal
+func (a *clientCertAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	state := c.Request().TLS
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	// the certificate must be verified during the handshake,
+	// e.g. tls.Config.ClientAuth is tls.VerifyClientCertIfGiven
+	if len(state.VerifiedChains) == 0 {
+		return nil, ErrInvalidClientCert
+	}
+
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	if cn == "" {
+		return nil, ErrInvalidClientCert
+	}
+
+	scopes := []Scope{}
+	for _, scope := range a.scopeMap[cn] {
+		if scope.Valid() {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &Principal{
+		ID:     cn,
+		Method: MethodClientCert,
+		Scopes: scopes,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package authn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/authn"
+)
+
+func TestClientCertAuthenticator(t *testing.T) {
+	ca, caKey := newTestCA(t, "invitesvc ca")
+	otherCA, otherCAKey := newTestCA(t, "other ca")
+
+	certAuth := authn.NewClientCertAuthenticator(map[string][]authn.Scope{
+		"ci-bot": {authn.ScopeTokensRead, authn.ScopeTokensWrite},
+	})
+
+	e := echo.New()
+	e.Use(authn.NewMiddleware(certAuth))
+	e.GET("/tokens", func(c echo.Context) error {
+		principal := authn.PrincipalFromContext(c)
+		return c.String(http.StatusOK, principal.ID)
+	}, authn.NewScopeMiddleware(authn.ScopeTokensRead))
+
+	srv := httptest.NewUnstartedServer(e)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv.TLS = &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(cert *tls.Certificate) (*http.Response, error) {
+		transport := srv.Client().Transport.(*http.Transport).Clone()
+		if cert != nil {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+		}
+
+		client := &http.Client{Transport: transport}
+		return client.Get(srv.URL + "/tokens")
+	}
+
+	t.Run("authorized", func(t *testing.T) {
+		cert := newTestClientCert(t, "ci-bot", ca, caKey)
+		resp, err := get(&cert)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("unmapped subject", func(t *testing.T) {
+		cert := newTestClientCert(t, "intern", ca, caKey)
+		resp, err := get(&cert)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		resp, err := get(nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("untrusted client certificate", func(t *testing.T) {
+		cert := newTestClientCert(t, "ci-bot", otherCA, otherCAKey)
+		resp, err := get(&cert)
+		if err == nil {
+			resp.Body.Close()
+			assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("unverified client certificate", func(t *testing.T) {
+		cert := newTestClientCert(t, "ci-bot", otherCA, otherCAKey)
+		req := httptest.NewRequest(http.MethodGet, "/tokens", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert.Leaf}}
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func newTestCA(t *testing.T, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func newTestClientCert(
+	t *testing.T,
+	cn string,
+	ca *x509.Certificate,
+	caKey *ecdsa.PrivateKey,
+) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
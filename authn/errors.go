@@ -0,0 +1,24 @@
+package authn
+
+import (
+	"github.com/pkg/errors"
+)
+
+// List of auth related errors
+var (
+	ErrAuthKeyNotFound   = errors.New("auth key not found")
+	ErrDuplicateAuthKey  = errors.New("auth key already exists")
+	ErrAuthKeyRevoked    = errors.New("auth key is revoked")
+	ErrAuthKeyExpired    = errors.New("auth key already expired")
+	ErrInvalidAuthKey    = errors.New("invalid auth key")
+	ErrAuthKeysExist     = errors.New("auth keys already exist")
+	ErrNoCredentials     = errors.New("no credentials")
+	ErrInvalidToken      = errors.New("invalid bearer token")
+	ErrInvalidSignature  = errors.New("invalid request signature")
+	ErrNonceReused       = errors.New("request nonce already used")
+	ErrSigningDisabled   = errors.New("request signing is not enabled")
+	ErrInvalidClientCert = errors.New("invalid client certificate")
+
+	ErrInvalidExpiration = errors.New("invalid auth key expiration")
+	ErrInvalidScopes     = errors.New("invalid auth key scopes")
+)
@@ -0,0 +1,11 @@
+package authn
+
+import "time"
+
+// ExpireJWKSRefresh allows the key set to be refreshed on the next unknown key id
+func ExpireJWKSRefresh(ks *JWKS) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.refreshedAt = time.Time{}
+}
@@ -0,0 +1,276 @@
+package authn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// minJWKSRefreshInterval is the min interval between
+	// refreshing a key set that was loaded from a url
+	minJWKSRefreshInterval = time.Minute
+	// maxJWKSSize is the max size of a key set document
+	maxJWKSSize = 1 << 20
+)
+
+// JWKS is a JSON web key set loaded from a local file or a url. Key
+// sets loaded from a url are refreshed when a key id is not found.
+type JWKS struct {
+	source string
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	refreshedAt time.Time
+}
+
+// LoadJWKS loads a key set from a local file or an http(s) url
+func LoadJWKS(source string) (*JWKS, error) {
+	ks := &JWKS{
+		source:      source,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		refreshedAt: time.Now(),
+	}
+
+	err := ks.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key returns the public key with the key id, the only key is
+// returned when the key id is empty and the key set has one key
+func (ks *JWKS) Key(kid string) (interface{}, error) {
+	ks.mu.Lock()
+	key, ok := ks.key(kid)
+	// the keys might have been rotated
+	refresh := !ok && ks.isRemote() &&
+		time.Since(ks.refreshedAt) > minJWKSRefreshInterval
+	if refresh {
+		// other callers don't refresh while the key set is loaded
+		ks.refreshedAt = time.Now()
+	}
+	ks.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if refresh {
+		err := ks.refresh()
+		if err != nil {
+			return nil, err
+		}
+
+		ks.mu.Lock()
+		key, ok = ks.key(kid)
+		ks.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+	}
+
+	return nil, errors.Errorf("key %q not found", kid)
+}
+
+// key returns the public key with the key id, mu must be held
+func (ks *JWKS) key(kid string) (interface{}, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// refresh reloads the key set, the key set is loaded without holding
+// mu so that the known keys can be used in the meantime
+func (ks *JWKS) refresh() error {
+	keys, err := ks.load()
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// load loads the key set from the source
+func (ks *JWKS) load() (map[string]interface{}, error) {
+	b, err := ks.read()
+	if err != nil {
+		return nil, errors.Wrap(err, "read jwks")
+	}
+
+	keys, err := ParseJWKS(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse jwks")
+	}
+
+	return keys, nil
+}
+
+// read reads the key set document from the source
+func (ks *JWKS) read() ([]byte, error) {
+	if !ks.isRemote() {
+		return os.ReadFile(ks.source)
+	}
+
+	resp, err := ks.client.Get(ks.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// isRemote returns true if the key set is loaded from a url
+func (ks *JWKS) isRemote() bool {
+	return strings.HasPrefix(ks.source, "https://") ||
+		strings.HasPrefix(ks.source, "http://")
+}
+
+// errUnsupportedKey is returned for the keys with an unsupported key type
+// or curve, these keys are skipped when parsing a key set
+var errUnsupportedKey = errors.New("unsupported key")
+
+// jsonWebKey is a JSON web key, only RSA and EC public keys are supported
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA public key
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC public key
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS parses a JSON web key set and returns the public keys by
+// key id, keys that are not used for signatures and keys with an
+// unsupported key type or curve are skipped. An error is returned if
+// no usable key remains.
+func ParseJWKS(b []byte) (map[string]interface{}, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := json.Unmarshal(b, &jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal jwks")
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %q", jwk.Kid)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no usable keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the public key of the JSON web key
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode n")
+		}
+
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode e")
+		}
+
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Wrapf(errUnsupportedKey, "curve %q", jwk.Crv)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode x")
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode y")
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, errors.Wrapf(errUnsupportedKey, "key type %q", jwk.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,207 @@
+package authn
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// bearerPrefix is the authorization header bearer prefix
+	bearerPrefix = "Bearer "
+	// defaultScopeClaim is the default claim containing the caller scopes
+	defaultScopeClaim = "scope"
+)
+
+// jwtSigningMethods is the list of accepted signing methods,
+// symmetric and none signing methods are never accepted
+var jwtSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// JWTConfig is the JWT bearer authenticator config
+type JWTConfig struct {
+	// Issuer is the expected token issuer
+	Issuer string
+	// Audience is the expected token audience
+	Audience string
+	// Keys is the key set used to verify the token signatures
+	Keys *JWKS
+	// ScopeClaim is the claim containing the caller scopes
+	// or roles, either a space separated string or an array
+	ScopeClaim string
+	// ScopeMap maps the scope claim values to scopes, the claim
+	// values are used as scopes if the scope map is not set
+	ScopeMap map[string][]Scope
+}
+
+// jwtAuthenticator authenticates requests with JWT bearer tokens
+type jwtAuthenticator struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator returns an authenticator that
+// authenticates requests with JWT bearer tokens
+func NewJWTAuthenticator(cfg JWTConfig) (Authenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("jwt issuer is required")
+	}
+
+	if cfg.Audience == "" {
+		return nil, errors.New("jwt audience is required")
+	}
+
+	if cfg.Keys == nil {
+		return nil, errors.New("jwt key set is required")
+	}
+
+	if cfg.ScopeClaim == "" {
+		cfg.ScopeClaim = defaultScopeClaim
+	}
+
+	return &jwtAuthenticator{
+		cfg: cfg,
+		parser: &jwt.Parser{
+			ValidMethods: jwtSigningMethods,
+		},
+	}, nil
+}
+
+// Authenticate verifies the bearer token and maps its claims to a principal
+func (a *jwtAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(header) <= len(bearerPrefix) ||
+		!strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(header[len(bearerPrefix):], claims, a.key)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	// the parser only verifies exp, iat and nbf if present
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no expiration")
+	}
+
+	if !claims.VerifyIssuer(a.cfg.Issuer, true) {
+		return nil, errors.Wrap(ErrInvalidToken, "invalid issuer")
+	}
+
+	if !claims.VerifyAudience(a.cfg.Audience, true) {
+		return nil, errors.Wrap(ErrInvalidToken, "invalid audience")
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no subject")
+	}
+
+	return &Principal{
+		ID:     sub,
+		Method: MethodJWT,
+		Scopes: a.scopes(claims[a.cfg.ScopeClaim]),
+	}, nil
+}
+
+// key returns the key used to verify the token signature
+func (a *jwtAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := a.cfg.Keys.Key(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key type must match the signing method
+	switch key.(type) {
+	case *rsa.PublicKey:
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return key, nil
+		}
+	}
+
+	return nil, errors.Errorf("signing method %s does not match key %q",
+		token.Method.Alg(), kid)
+}
+
+// scopes maps the scope claim to scopes
+func (a *jwtAuthenticator) scopes(claim interface{}) []Scope {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, value := range v {
+			s, ok := value.(string)
+			if ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	scopes := []Scope{}
+	seen := map[Scope]bool{}
+	add := func(scope Scope) {
+		if scope.Valid() && !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	for _, value := range values {
+		if a.cfg.ScopeMap == nil {
+			add(Scope(value))
+			continue
+		}
+
+		for _, scope := range a.cfg.ScopeMap[value] {
+			add(scope)
+		}
+	}
+
+	return scopes
+}
+
+// ParseScopeMap parses a scope map in the form of
+// "role=scope+scope,role2=scope", e.g.
+// "support=tokens:read,admin=tokens:read+tokens:write+keys:admin"
+func ParseScopeMap(s string) (map[string][]Scope, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	scopeMap := map[string][]Scope{}
+	for _, entry := range strings.Split(s, ",") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, errors.Errorf("invalid scope map entry %q", entry)
+		}
+
+		value := strings.TrimSpace(kv[0])
+		for _, scope := range strings.Split(kv[1], "+") {
+			scope := Scope(strings.TrimSpace(scope))
+			if !scope.Valid() {
+				return nil, errors.Wrapf(ErrInvalidScopes, "scope %q", scope)
+			}
+
+			scopeMap[value] = append(scopeMap[value], scope)
+		}
+	}
+
+	return scopeMap, nil
+}
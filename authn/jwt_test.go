@@ -0,0 +1,367 @@
+package authn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/authn"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "invitesvc"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(jwksFile, mustMarshalJWKS(t, map[string]interface{}{
+		"rsa": &rsaKey.PublicKey,
+		"ec":  &ecKey.PublicKey,
+	}), 0600)
+	require.NoError(t, err)
+
+	keys, err := authn.LoadJWKS(jwksFile)
+	require.NoError(t, err)
+
+	scopeMap, err := authn.ParseScopeMap("support=tokens:read,admin=tokens:read+tokens:write+keys:admin")
+	require.NoError(t, err)
+
+	jwtAuth, err := authn.NewJWTAuthenticator(authn.JWTConfig{
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		Keys:       keys,
+		ScopeClaim: "roles",
+		ScopeMap:   scopeMap,
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(authn.NewMiddleware(jwtAuth))
+	e.GET("/tokens", func(c echo.Context) error {
+		principal := authn.PrincipalFromContext(c)
+		return c.String(http.StatusOK, principal.ID)
+	}, authn.NewScopeMiddleware(authn.ScopeTokensRead))
+	e.GET("/keys", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, authn.NewScopeMiddleware(authn.ScopeKeysAdmin))
+
+	do := func(path, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if bearer != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+bearer)
+		}
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		return rr
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   testIssuer,
+			"aud":   []string{testAudience, "other"},
+			"sub":   "alice@example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": []string{"support"},
+		}
+	}
+
+	t.Run("authorized", func(t *testing.T) {
+		bearer := signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims())
+		rr := do("/tokens", bearer)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "alice@example.com", rr.Body.String())
+
+		bearer = signJWT(t, jwt.SigningMethodES256, ecKey, "ec", validClaims())
+		rr = do("/tokens", bearer)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("scope mapping", func(t *testing.T) {
+		bearer := signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims())
+		rr := do("/keys", bearer)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		claims := validClaims()
+		claims["roles"] = "admin"
+		bearer = signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims)
+		rr = do("/keys", bearer)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// unmapped roles grant nothing
+		claims["roles"] = []string{"keys:admin", "guest"}
+		bearer = signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims)
+		rr = do("/tokens", bearer)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("un-authorized", func(t *testing.T) {
+		// no credentials
+		rr := do("/tokens", "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		rr = do("/tokens", "not-a-jwt")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		tests := []struct {
+			name   string
+			modify func(jwt.MapClaims)
+		}{
+			{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+			{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other" }},
+			{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+			{"no expiration", func(c jwt.MapClaims) { delete(c, "exp") }},
+			{"not yet valid", func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+			{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				claims := validClaims()
+				tc.modify(claims)
+				bearer := signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims)
+				rr := do("/tokens", bearer)
+				assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			})
+		}
+
+		t.Run("unknown key", func(t *testing.T) {
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			require.NoError(t, err)
+
+			bearer := signJWT(t, jwt.SigningMethodRS256, otherKey, "other", validClaims())
+			rr := do("/tokens", bearer)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			// known key id, wrong key
+			bearer = signJWT(t, jwt.SigningMethodRS256, otherKey, "rsa", validClaims())
+			rr = do("/tokens", bearer)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+
+		t.Run("signing method", func(t *testing.T) {
+			// key type mismatch
+			bearer := signJWT(t, jwt.SigningMethodES256, ecKey, "rsa", validClaims())
+			rr := do("/tokens", bearer)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			// symmetric signing with the public key
+			pub, err := json.Marshal(rsaKey.PublicKey)
+			require.NoError(t, err)
+			bearer = signJWT(t, jwt.SigningMethodHS256, pub, "rsa", validClaims())
+			rr = do("/tokens", bearer)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			bearer = signJWT(t, jwt.SigningMethodNone,
+				jwt.UnsafeAllowNoneSignatureType, "rsa", validClaims())
+			rr = do("/tokens", bearer)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+}
+
+func TestLoadJWKSFromURL(t *testing.T) {
+	key1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	key2, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks := mustMarshalJWKS(t, map[string]interface{}{"key1": &key1.PublicKey})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	keys, err := authn.LoadJWKS(srv.URL + "/jwks.json")
+	require.NoError(t, err)
+
+	key, err := keys.Key("key1")
+	require.NoError(t, err)
+	assert.Equal(t, &key1.PublicKey, key)
+
+	// keys are not refreshed too often
+	jwks = mustMarshalJWKS(t, map[string]interface{}{
+		"key1": &key1.PublicKey,
+		"key2": &key2.PublicKey,
+	})
+	_, err = keys.Key("key2")
+	assert.Error(t, err)
+
+	_, err = authn.LoadJWKS(srv.URL + "/missing")
+	assert.Error(t, err)
+
+	t.Run("refresh", func(t *testing.T) {
+		var requests int32
+		fetching, release := make(chan struct{}), make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys := map[string]interface{}{"key1": &key1.PublicKey}
+			if atomic.AddInt32(&requests, 1) > 1 {
+				// the refresh blocks until released
+				close(fetching)
+				<-release
+				keys["key2"] = &key2.PublicKey
+			}
+			_, _ = w.Write(mustMarshalJWKS(t, keys))
+		}))
+		defer srv.Close()
+
+		keys, err := authn.LoadJWKS(srv.URL)
+		require.NoError(t, err)
+
+		authn.ExpireJWKSRefresh(keys)
+		done := make(chan error)
+		go func() {
+			_, err := keys.Key("key2")
+			done <- err
+		}()
+		<-fetching
+
+		// the known keys can be used while the key set is refreshed
+		got := make(chan interface{})
+		go func() {
+			key, _ := keys.Key("key1")
+			got <- key
+		}()
+		select {
+		case key := <-got:
+			assert.Equal(t, &key1.PublicKey, key)
+		case <-time.After(5 * time.Second):
+			close(release)
+			t.Fatal("key blocked by the refresh")
+		}
+
+		close(release)
+		require.NoError(t, <-done)
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	err = json.Unmarshal(mustMarshalJWKS(t, map[string]interface{}{
+		"ec": &key.PublicKey,
+	}), &jwks)
+	require.NoError(t, err)
+
+	unsupported := []map[string]string{
+		{"kty": "OKP", "kid": "okp", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{"kty": "EC", "kid": "secp256k1", "crv": "secp256k1", "x": "AQ", "y": "AQ"},
+		{"kty": "oct", "kid": "oct", "k": "c2VjcmV0"},
+	}
+
+	// unsupported keys are skipped
+	b, err := json.Marshal(map[string]interface{}{
+		"keys": append(unsupported, jwks.Keys...),
+	})
+	require.NoError(t, err)
+
+	keys, err := authn.ParseJWKS(b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ec": &key.PublicKey}, keys)
+
+	// no usable keys
+	b, err = json.Marshal(map[string]interface{}{"keys": unsupported})
+	require.NoError(t, err)
+
+	_, err = authn.ParseJWKS(b)
+	assert.Error(t, err)
+
+	// invalid keys of a supported type are not skipped
+	jwks.Keys[0]["x"] = "AQ"
+	b, err = json.Marshal(jwks)
+	require.NoError(t, err)
+
+	_, err = authn.ParseJWKS(b)
+	assert.Error(t, err)
+}
+
+func TestParseScopeMap(t *testing.T) {
+	scopeMap, err := authn.ParseScopeMap("")
+	require.NoError(t, err)
+	assert.Nil(t, scopeMap)
+
+	scopeMap, err = authn.ParseScopeMap("support=tokens:read, admin=tokens:read+keys:admin")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]authn.Scope{
+		"support": {authn.ScopeTokensRead},
+		"admin":   {authn.ScopeTokensRead, authn.ScopeKeysAdmin},
+	}, scopeMap)
+
+	_, err = authn.ParseScopeMap("support=tokens:delete")
+	assert.ErrorIs(t, err, authn.ErrInvalidScopes)
+
+	_, err = authn.ParseScopeMap("support")
+	assert.Error(t, err)
+}
+
+func signJWT(
+	t *testing.T,
+	method jwt.SigningMethod,
+	key interface{},
+	kid string,
+	claims jwt.MapClaims,
+) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func mustMarshalJWKS(t *testing.T, keys map[string]interface{}) []byte {
+	b64 := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	jwks := []map[string]string{}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": b64(k.N), "e": b64(big.NewInt(int64(k.E))),
+			})
+		case *ecdsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "EC", "kid": kid, "use": "sig",
+				"crv": k.Curve.Params().Name,
+				"x":   b64(k.X), "y": b64(k.Y),
+			})
+		}
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"keys": jwks})
+	require.NoError(t, err)
+	return b
+}
@@ -6,8 +6,21 @@ import (
 
 // Repository is an auth repository
 type Repository interface {
-	// CreateAuthKey creates an auth key
-	CreateAuthKey(context.Context, AuthKey) error
-	// AuthKeyExists checks if auth key exists in db
-	AuthKeyExists(context.Context, AuthKey) (bool, error)
+	// CreateAuthKey creates an auth record, ErrDuplicateAuthKey
+	// is returned if an auth record with the same id exists
+	CreateAuthKey(context.Context, *Auth) error
+	// CreateInitialAuthKey atomically creates an auth record only when
+	// there are no auth records yet, otherwise ErrAuthKeysExist is returned
+	CreateInitialAuthKey(context.Context, *Auth) error
+	// GetAuthKey retrieves an auth record by auth key id from db
+	GetAuthKey(context.Context, KeyID) (*Auth, error)
+	// ListAuthKeys retrieves all auth records from db
+	ListAuthKeys(context.Context) ([]*Auth, error)
+	// SetAuthKeyRevoked sets an auth key to revoked
+	SetAuthKeyRevoked(context.Context, KeyID) error
+	// SetAuthKeyUsed sets the last used timestamp of an auth key to now
+	SetAuthKeyUsed(context.Context, KeyID) error
+	// CreateNonce records a request nonce until it expires, ErrNonceReused
+	// is returned if the nonce was already used with the auth key
+	CreateNonce(context.Context, *Nonce) error
 }
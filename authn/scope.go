@@ -0,0 +1,29 @@
+package authn
+
+// Scope is an auth key permission
+type Scope string
+
+// List of auth key scopes
+const (
+	// ScopeTokensRead allows retrieving and listing tokens
+	ScopeTokensRead Scope = "tokens:read"
+	// ScopeTokensWrite allows generating and updating tokens
+	ScopeTokensWrite Scope = "tokens:write"
+	// ScopeKeysAdmin allows generating, listing and revoking auth keys
+	ScopeKeysAdmin Scope = "keys:admin"
+	// ScopeAuditRead allows listing audit events
+	ScopeAuditRead Scope = "audit:read"
+)
+
+// Scopes is the list of auth key scopes
+var Scopes = []Scope{ScopeTokensRead, ScopeTokensWrite, ScopeKeysAdmin, ScopeAuditRead}
+
+// Valid returns true if the scope is a known scope
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeTokensRead, ScopeTokensWrite, ScopeKeysAdmin, ScopeAuditRead:
+		return true
+	}
+
+	return false
+}
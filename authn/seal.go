@@ -0,0 +1,74 @@
+package authn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MinSigningSecretLen is the min len of the secret used for sealing signing keys
+const MinSigningSecretLen = 32
+
+// sealedPrefix is the prefix of sealed signing keys
+const sealedPrefix = "sealed:v1:"
+
+// newSigningAEAD returns the AES-256-GCM cipher used for sealing the
+// signing keys, the cipher key is the sha256 hash of the secret
+func newSigningAEAD(secret string) cipher.AEAD {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		// unreachable, the key is always 32 bytes
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	return aead
+}
+
+// sealSigningKey encrypts the signing key so that it's not stored in
+// plaintext, the key id is authenticated so that a sealed signing key
+// can't be copied to another auth key
+func sealSigningKey(aead cipher.AEAD, id KeyID, signingKey string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", errors.Wrap(err, "generate nonce")
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(signingKey), []byte(id))
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openSigningKey decrypts a signing key sealed with sealSigningKey
+func openSigningKey(aead cipher.AEAD, id KeyID, sealed string) (string, error) {
+	if !strings.HasPrefix(sealed, sealedPrefix) {
+		return "", errors.New("signing key is not sealed")
+	}
+
+	b, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return "", errors.Wrap(err, "decode signing key")
+	}
+
+	if len(b) < aead.NonceSize() {
+		return "", errors.New("sealed signing key too short")
+	}
+
+	nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+	signingKey, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", errors.Wrap(err, "open signing key")
+	}
+
+	return string(signingKey), nil
+}
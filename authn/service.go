@@ -2,36 +2,337 @@ package authn
 
 import (
 	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Service is an auth service
 type Service interface {
-	// GenerateAuthKey generates an auth key
-	GenerateAuthKey(context.Context) (AuthKey, error)
-	// AuthKeyExists checks if auth key exists in db
-	AuthKeyExists(context.Context, AuthKey) (bool, error)
+	// GenerateAuthKey generates an auth key, only the hash of the
+	// auth key is stored so it can't be retrieved afterwards
+	GenerateAuthKey(context.Context, GenerateParams) (AuthKey, error)
+	// BootstrapAuthKey creates the initial auth key
+	BootstrapAuthKey(context.Context, AuthKey) (AuthKey, error)
+	// VerifyAuthKey verifies the auth key and returns its auth record
+	VerifyAuthKey(context.Context, AuthKey) (*Auth, error)
+	// VerifySignedRequest verifies the request signature and nonce
+	// and returns the auth record of the auth key used for signing
+	VerifySignedRequest(context.Context, SignedRequest) (*Auth, error)
+	// TouchAuthKey records that the auth key was used
+	TouchAuthKey(context.Context, KeyID) error
+	// ListAuthKeys retrieves the auth records of all auth keys
+	ListAuthKeys(context.Context) ([]*Auth, error)
+	// RevokeAuthKey revokes an auth key
+	RevokeAuthKey(context.Context, KeyID) error
+}
+
+// maxGenerateAttempts is the max number of attempts
+// when generating auth keys with colliding ids
+const maxGenerateAttempts = 3
+
+// GenerateParams is the auth key generation parameters
+type GenerateParams struct {
+	// Name is the auth key name
+	Name string
+	// Owner is the auth key owner
+	Owner string
+	// Scopes is the list of permissions granted
+	// to the auth key, at least one is required
+	Scopes []Scope
+	// TTL is the auth key time-to-live
+	TTL time.Duration
+	// ExpiresAt is the auth key expiration timestamp, the
+	// auth key never expires when both TTL and ExpiresAt are not set
+	ExpiresAt *time.Time
+	// Signing enables request signing with the auth key
+	Signing bool
 }
 
 // authService implements auth service
 type authService struct {
-	authRepo Repository
+	authRepo     Repository
+	maxClockSkew time.Duration
+	// signingAEAD seals the signing keys, request
+	// signing is disabled when it's not set
+	signingAEAD cipher.AEAD
 }
 
 var _ Service = (*authService)(nil)
 
+// Option is an auth service option
+type Option func(*authService)
+
+// WithMaxClockSkew sets the max difference between
+// the signed request timestamp and the server time
+func WithMaxClockSkew(skew time.Duration) Option {
+	return func(svc *authService) {
+		svc.maxClockSkew = skew
+	}
+}
+
+// WithSigningSecret enables request signing, the signing keys are
+// sealed with the secret so that they can't be used to sign requests
+// when the auth keys are leaked from the db
+func WithSigningSecret(secret string) Option {
+	return func(svc *authService) {
+		svc.signingAEAD = newSigningAEAD(secret)
+	}
+}
+
 // NewAuthService takes an auth repo and returns an auth service
-func NewAuthService(authRepo Repository) Service {
-	return &authService{authRepo: authRepo}
+func NewAuthService(authRepo Repository, opts ...Option) Service {
+	svc := &authService{authRepo: authRepo, maxClockSkew: DefaultMaxClockSkew}
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
+}
+
+func (svc *authService) GenerateAuthKey(ctx context.Context, params GenerateParams) (AuthKey, error) {
+	scopes, err := validScopes(params.Scopes)
+	if err != nil {
+		return NilAuthKey, err
+	}
+
+	expiresAt, err := expiresAt(params)
+	if err != nil {
+		return NilAuthKey, err
+	}
+
+	if params.Signing && svc.signingAEAD == nil {
+		return NilAuthKey, ErrSigningDisabled
+	}
+
+	// retry with a new auth key when the auth key id already exists
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		authKey := NewAuthKey()
+		auth, err := NewAuth(authKey)
+		if err != nil {
+			return NilAuthKey, errors.Wrap(err, "new auth")
+		}
+
+		auth.Name = params.Name
+		auth.Owner = params.Owner
+		auth.Scopes = scopes
+		auth.ExpiresAt = expiresAt
+		if params.Signing {
+			auth.SigningKey, err = sealSigningKey(svc.signingAEAD,
+				auth.ID, DeriveSigningKey(authKey))
+			if err != nil {
+				return NilAuthKey, errors.Wrap(err, "seal signing key")
+			}
+		}
+
+		err = svc.authRepo.CreateAuthKey(ctx, auth)
+		if err != nil {
+			if errors.Is(err, ErrDuplicateAuthKey) {
+				continue
+			}
+
+			return NilAuthKey, errors.Wrap(err, "create auth key")
+		}
+
+		return authKey, nil
+	}
+
+	return NilAuthKey, errors.Errorf("generate auth key: id collision after %d attempts", maxGenerateAttempts)
+}
+
+// minSeedLen is the min len of a bootstrap auth key seed
+const minSeedLen = 32
+
+// BootstrapAuthKey creates the initial auth key with all scopes when
+// there are no auth keys yet, otherwise ErrAuthKeysExist is returned.
+// The seed is used as the auth key when set, a new auth key is
+// generated when not set.
+func (svc *authService) BootstrapAuthKey(ctx context.Context, seed AuthKey) (AuthKey, error) {
+	authKey := seed
+	if authKey == NilAuthKey {
+		authKey = NewAuthKey()
+	}
+
+	if len(authKey) < minSeedLen {
+		return NilAuthKey, ErrInvalidAuthKey
+	}
+
+	auth, err := NewAuth(authKey)
+	if err != nil {
+		return NilAuthKey, errors.Wrap(err, "new auth")
+	}
+
+	auth.Name = "bootstrap"
+	auth.Scopes = Scopes
+
+	err = svc.authRepo.CreateInitialAuthKey(ctx, auth)
+	if err != nil {
+		if errors.Is(err, ErrAuthKeysExist) {
+			return NilAuthKey, err
+		}
+
+		return NilAuthKey, errors.Wrap(err, "create initial auth key")
+	}
+
+	return authKey, nil
+}
+
+// validScopes validates the scopes and returns them without duplicates
+func validScopes(scopes []Scope) ([]Scope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScopes
+	}
+
+	seen := make(map[Scope]struct{}, len(scopes))
+	valid := make([]Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, ErrInvalidScopes
+		}
+
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+
+		seen[scope] = struct{}{}
+		valid = append(valid, scope)
+	}
+
+	return valid, nil
+}
+
+// expiresAt returns the auth key expiration from the generate params
+func expiresAt(params GenerateParams) (*time.Time, error) {
+	now := time.Now()
+	switch {
+	case params.TTL != 0 && params.ExpiresAt != nil:
+		return nil, ErrInvalidExpiration
+	case params.ExpiresAt != nil:
+		if !params.ExpiresAt.After(now) {
+			return nil, ErrInvalidExpiration
+		}
+
+		expiresAt := *params.ExpiresAt
+		return &expiresAt, nil
+	case params.TTL < 0:
+		return nil, ErrInvalidExpiration
+	case params.TTL > 0:
+		expiresAt := now.Add(params.TTL)
+		return &expiresAt, nil
+	}
+
+	return nil, nil
 }
 
-func (svc *authService) GenerateAuthKey(ctx context.Context) (AuthKey, error) {
-	authKey := NewAuthKey()
-	err := svc.authRepo.CreateAuthKey(ctx, authKey)
-	return authKey, errors.Wrap(err, "create auth key")
+func (svc *authService) VerifyAuthKey(ctx context.Context, authKey AuthKey) (*Auth, error) {
+	keyID := authKey.ID()
+	if keyID == NilKeyID {
+		return nil, ErrInvalidAuthKey
+	}
+
+	auth, err := svc.authRepo.GetAuthKey(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, ErrAuthKeyNotFound) {
+			return nil, ErrInvalidAuthKey
+		}
+
+		return nil, errors.Wrap(err, "get auth key")
+	}
+
+	if !auth.Matches(authKey) {
+		return nil, ErrInvalidAuthKey
+	}
+
+	err = auth.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return auth, nil
 }
 
-func (svc *authService) AuthKeyExists(ctx context.Context, authKey AuthKey) (bool, error) {
-	return svc.authRepo.AuthKeyExists(ctx, authKey)
+// VerifySignedRequest verifies that the request was signed within the
+// max clock skew with the signing key of a valid auth key, and records
+// the nonce so that the same request can't be replayed
+func (svc *authService) VerifySignedRequest(ctx context.Context, sr SignedRequest) (*Auth, error) {
+	skew := time.Since(sr.Timestamp)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > svc.maxClockSkew {
+		return nil, errors.Wrap(ErrInvalidSignature, "timestamp outside of allowed clock skew")
+	}
+
+	if len(sr.Nonce) < minNonceLen || len(sr.Nonce) > maxNonceLen {
+		return nil, errors.Wrap(ErrInvalidSignature, "invalid nonce")
+	}
+
+	auth, err := svc.authRepo.GetAuthKey(ctx, sr.KeyID)
+	if err != nil {
+		if errors.Is(err, ErrAuthKeyNotFound) {
+			return nil, ErrInvalidSignature
+		}
+
+		return nil, errors.Wrap(err, "get auth key")
+	}
+
+	// request signing is not enabled for the auth key or the service
+	if auth.SigningKey == "" || svc.signingAEAD == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	signingKey, err := openSigningKey(svc.signingAEAD, auth.ID, auth.SigningKey)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+
+	if !hmac.Equal([]byte(sr.Sign(signingKey)), []byte(sr.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	err = auth.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// the nonce only needs to be kept until the timestamp is outside of the skew
+	err = svc.authRepo.CreateNonce(ctx, &Nonce{
+		KeyID:     auth.ID,
+		Value:     sr.Nonce,
+		ExpiresAt: sr.Timestamp.Add(svc.maxClockSkew),
+	})
+	if err != nil {
+		if errors.Is(err, ErrNonceReused) {
+			return nil, err
+		}
+
+		return nil, errors.Wrap(err, "create nonce")
+	}
+
+	return auth, nil
+}
+
+func (svc *authService) TouchAuthKey(ctx context.Context, id KeyID) error {
+	return svc.authRepo.SetAuthKeyUsed(ctx, id)
+}
+
+func (svc *authService) ListAuthKeys(ctx context.Context) ([]*Auth, error) {
+	auths, err := svc.authRepo.ListAuthKeys(ctx)
+	return auths, errors.Wrap(err, "list auth keys")
+}
+
+func (svc *authService) RevokeAuthKey(ctx context.Context, id KeyID) error {
+	auth, err := svc.authRepo.GetAuthKey(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "get auth key")
+	}
+
+	if auth.Revoked() {
+		return ErrAuthKeyRevoked
+	}
+
+	return svc.authRepo.SetAuthKeyRevoked(ctx, id)
 }
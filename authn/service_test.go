@@ -3,37 +3,264 @@ package authn_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stevenferrer/invitesvc/authn"
-	"github.com/stevenferrer/invitesvc/postgres"
-	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/storagetest"
 )
 
+// signingSecret is the secret used for sealing the signing keys
+const signingSecret = "0123456789abcdef0123456789abcdef"
+
 func TestAuthService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
+	storagetest.Run(t, testAuthService)
+}
+
+func testAuthService(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	authRepo := repos.Auth
+	authSvc := authn.NewAuthService(authRepo, authn.WithSigningSecret(signingSecret))
+
+	ctx := context.TODO()
+	authKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+		Name:   "support dashboard",
+		Owner:  "support@example.com",
+		Scopes: []authn.Scope{authn.ScopeTokensRead, authn.ScopeTokensRead},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, authKey)
+
+	// verify auth key
+	auth, err := authSvc.VerifyAuthKey(ctx, authKey)
+	require.NoError(t, err)
+	assert.Equal(t, authKey.ID(), auth.ID)
+	assert.Equal(t, "support dashboard", auth.Name)
+	assert.Equal(t, "support@example.com", auth.Owner)
+	assert.Nil(t, auth.ExpiresAt)
+	assert.Equal(t, []authn.Scope{authn.ScopeTokensRead}, auth.Scopes)
+	assert.True(t, auth.HasScope(authn.ScopeTokensRead))
+	assert.False(t, auth.HasScope(authn.ScopeKeysAdmin))
+
+	// only the hash of the auth key is stored
+	assert.NotContains(t, auth.Hash, string(authKey))
+
+	t.Run("invalid auth key", func(t *testing.T) {
+		_, err = authSvc.VerifyAuthKey(ctx, authn.NewAuthKey())
+		assert.ErrorIs(t, err, authn.ErrInvalidAuthKey)
+
+		// same auth key id but different secret
+		wrongKey := authKey[:8] + authn.NewAuthKey()[8:]
+		_, err = authSvc.VerifyAuthKey(ctx, wrongKey)
+		assert.ErrorIs(t, err, authn.ErrInvalidAuthKey)
+
+		// malformed auth key
+		_, err = authSvc.VerifyAuthKey(ctx, "abc")
+		assert.ErrorIs(t, err, authn.ErrInvalidAuthKey)
+	})
+
+	t.Run("touch auth key", func(t *testing.T) {
+		err = authSvc.TouchAuthKey(ctx, authKey.ID())
+		require.NoError(t, err)
+
+		auth, err := authSvc.VerifyAuthKey(ctx, authKey)
+		require.NoError(t, err)
+		assert.NotNil(t, auth.LastUsedAt)
+	})
+
+	t.Run("expiring auth key", func(t *testing.T) {
+		authKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: authn.Scopes,
+			TTL:    time.Hour,
+		})
+		require.NoError(t, err)
+
+		auth, err := authSvc.VerifyAuthKey(ctx, authKey)
+		require.NoError(t, err)
+		require.NotNil(t, auth.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *auth.ExpiresAt, time.Minute)
+
+		past := time.Now().Add(-time.Hour)
+		_, err = authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes:    authn.Scopes,
+			ExpiresAt: &past,
+		})
+		assert.ErrorIs(t, err, authn.ErrInvalidExpiration)
+	})
+
+	t.Run("invalid scopes", func(t *testing.T) {
+		_, err = authSvc.GenerateAuthKey(ctx, authn.GenerateParams{})
+		assert.ErrorIs(t, err, authn.ErrInvalidScopes)
+
+		_, err = authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: []authn.Scope{"tokens:delete"},
+		})
+		assert.ErrorIs(t, err, authn.ErrInvalidScopes)
+	})
+
+	t.Run("list auth keys", func(t *testing.T) {
+		auths, err := authSvc.ListAuthKeys(ctx)
+		require.NoError(t, err)
+		assert.Len(t, auths, 2)
+	})
+
+	t.Run("revoke auth key", func(t *testing.T) {
+		err = authSvc.RevokeAuthKey(ctx, authKey.ID())
+		require.NoError(t, err)
+
+		_, err = authSvc.VerifyAuthKey(ctx, authKey)
+		assert.ErrorIs(t, err, authn.ErrAuthKeyRevoked)
+
+		err = authSvc.RevokeAuthKey(ctx, authKey.ID())
+		assert.ErrorIs(t, err, authn.ErrAuthKeyRevoked)
+
+		err = authSvc.RevokeAuthKey(ctx, authn.NewAuthKey().ID())
+		assert.ErrorIs(t, err, authn.ErrAuthKeyNotFound)
+	})
 
-	// migrate db
-	postgres.MustMigrate(db)
+	t.Run("verify signed request", func(t *testing.T) {
+		signingKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes:  []authn.Scope{authn.ScopeTokensWrite},
+			Signing: true,
+		})
+		require.NoError(t, err)
 
-	authRepo := postgres.NewAuthRepository(db)
+		newSignedRequest := func(authKey authn.AuthKey, ts time.Time) authn.SignedRequest {
+			sr := authn.SignedRequest{
+				KeyID:     authKey.ID(),
+				Timestamp: ts,
+				Nonce:     string(authn.NewAuthKey()),
+				Method:    "POST",
+				Path:      "/admin/tokens",
+				BodyHash:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}
+			sr.Signature = sr.Sign(authn.DeriveSigningKey(authKey))
+			return sr
+		}
+
+		sr := newSignedRequest(signingKey, time.Now())
+		auth, err := authSvc.VerifySignedRequest(ctx, sr)
+		require.NoError(t, err)
+		assert.Equal(t, signingKey.ID(), auth.ID)
+
+		// replayed request
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrNonceReused)
+
+		// timestamp within the clock skew
+		sr = newSignedRequest(signingKey, time.Now().Add(-time.Minute))
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		require.NoError(t, err)
+
+		// timestamp outside of the clock skew
+		sr = newSignedRequest(signingKey, time.Now().Add(-time.Hour))
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		sr = newSignedRequest(signingKey, time.Now().Add(time.Hour))
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// tampered request
+		sr = newSignedRequest(signingKey, time.Now())
+		sr.Path = "/admin/authkey"
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// short nonce
+		sr = newSignedRequest(signingKey, time.Now())
+		sr.Nonce = "abc"
+		sr.Signature = sr.Sign(authn.DeriveSigningKey(signingKey))
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// request signing is not enabled
+		plainKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: []authn.Scope{authn.ScopeTokensWrite},
+		})
+		require.NoError(t, err)
+
+		sr = newSignedRequest(plainKey, time.Now())
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// unknown auth key
+		sr = newSignedRequest(authn.NewAuthKey(), time.Now())
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// the stored signing key can't be used for signing
+		stored, err := authRepo.GetAuthKey(ctx, signingKey.ID())
+		require.NoError(t, err)
+		require.NotEmpty(t, stored.SigningKey)
+		assert.NotContains(t, stored.SigningKey, authn.DeriveSigningKey(signingKey))
+
+		sr = newSignedRequest(signingKey, time.Now())
+		sr.Signature = sr.Sign(stored.SigningKey)
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// the signing key can't be opened without the secret
+		otherSvc := authn.NewAuthService(authRepo,
+			authn.WithSigningSecret("fedcba9876543210fedcba9876543210"))
+		sr = newSignedRequest(signingKey, time.Now())
+		_, err = otherSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// request signing is not enabled for the service
+		noSigningSvc := authn.NewAuthService(authRepo)
+		_, err = noSigningSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes:  []authn.Scope{authn.ScopeTokensWrite},
+			Signing: true,
+		})
+		assert.ErrorIs(t, err, authn.ErrSigningDisabled)
+
+		sr = newSignedRequest(signingKey, time.Now())
+		_, err = noSigningSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrInvalidSignature)
+
+		// revoked auth key
+		err = authSvc.RevokeAuthKey(ctx, signingKey.ID())
+		require.NoError(t, err)
+
+		sr = newSignedRequest(signingKey, time.Now())
+		_, err = authSvc.VerifySignedRequest(ctx, sr)
+		assert.ErrorIs(t, err, authn.ErrAuthKeyRevoked)
+	})
+}
+
+func TestBootstrapAuthKey(t *testing.T) {
+	storagetest.Run(t, testBootstrapAuthKey)
+}
+
+func testBootstrapAuthKey(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	authRepo := repos.Auth
 	authSvc := authn.NewAuthService(authRepo)
 
 	ctx := context.TODO()
-	authKey, err := authSvc.GenerateAuthKey(ctx)
+
+	// seed is too short
+	_, err := authSvc.BootstrapAuthKey(ctx, "abc")
+	assert.ErrorIs(t, err, authn.ErrInvalidAuthKey)
+
+	seed := authn.NewAuthKey()
+	authKey, err := authSvc.BootstrapAuthKey(ctx, seed)
 	require.NoError(t, err)
-	assert.NotEmpty(t, authKey)
+	assert.Equal(t, seed, authKey)
 
-	// check auth key exists
-	exists, err := authSvc.AuthKeyExists(ctx, authKey)
+	// bootstrap key has all scopes
+	auth, err := authSvc.VerifyAuthKey(ctx, authKey)
 	require.NoError(t, err)
-	assert.True(t, exists)
+	assert.ElementsMatch(t, authn.Scopes, auth.Scopes)
+
+	// auth keys exist already
+	_, err = authSvc.BootstrapAuthKey(ctx, authn.NilAuthKey)
+	assert.ErrorIs(t, err, authn.ErrAuthKeysExist)
 
-	// check auth key not exists
-	exists, err = authRepo.AuthKeyExists(ctx, authn.NewAuthKey())
+	auths, err := authSvc.ListAuthKeys(ctx)
 	require.NoError(t, err)
-	assert.False(t, exists)
+	assert.Len(t, auths, 1)
 }
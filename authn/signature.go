@@ -0,0 +1,205 @@
+package authn
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// List of request signing headers
+const (
+	// SignatureKeyIDHeader is the id of the auth key used for signing
+	SignatureKeyIDHeader = "X-AUTH-KEY-ID"
+	// SignatureTimestampHeader is the unix timestamp of the request
+	SignatureTimestampHeader = "X-AUTH-TIMESTAMP"
+	// SignatureNonceHeader is the unique request nonce
+	SignatureNonceHeader = "X-AUTH-NONCE"
+	// SignatureHeader is the hex encoded request signature
+	SignatureHeader = "X-AUTH-SIGNATURE"
+)
+
+const (
+	// MethodSignature is the signed request authentication method
+	MethodSignature = "signature"
+
+	// DefaultMaxClockSkew is the default max difference between
+	// the signed request timestamp and the server time
+	DefaultMaxClockSkew = 5 * time.Minute
+
+	// minNonceLen and maxNonceLen are the nonce length bounds
+	minNonceLen = 16
+	maxNonceLen = 128
+
+	// maxSignedBodySize is the max size of a signed request body
+	maxSignedBodySize = 1 << 20
+)
+
+// SignedRequest is a request signed with the signing key of an auth key
+type SignedRequest struct {
+	// KeyID is the id of the auth key used for signing
+	KeyID KeyID
+	// Timestamp is the time the request was signed
+	Timestamp time.Time
+	// Nonce is a unique value that prevents replaying the request
+	Nonce string
+	// Signature is the hex encoded request signature
+	Signature string
+	// Method is the request method
+	Method string
+	// Path is the request path including the query string
+	Path string
+	// BodyHash is the hex encoded sha256 hash of the request body
+	BodyHash string
+}
+
+// StringToSign returns the string that is signed, which is the method,
+// path, body hash, unix timestamp and nonce separated by new lines
+func (r SignedRequest) StringToSign() string {
+	return strings.Join([]string{
+		r.Method,
+		r.Path,
+		r.BodyHash,
+		strconv.FormatInt(r.Timestamp.Unix(), 10),
+		r.Nonce,
+	}, "\n")
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the request
+func (r SignedRequest) Sign(signingKey string) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(r.StringToSign()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Nonce is a used request nonce
+type Nonce struct {
+	// KeyID is the id of the auth key used for signing
+	KeyID KeyID
+	// Value is the nonce value
+	Value string
+	// ExpiresAt is the time after which the signed
+	// request is rejected and the nonce can be removed
+	ExpiresAt time.Time
+}
+
+// SignRequest signs the request with the signing key derived from the auth key
+func SignRequest(r *http.Request, authKey AuthKey) error {
+	body, err := readBody(r)
+	if err != nil {
+		return errors.Wrap(err, "read body")
+	}
+
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+
+	sr := SignedRequest{
+		KeyID:     authKey.ID(),
+		Timestamp: time.Now(),
+		Nonce:     hex.EncodeToString(b),
+		Method:    r.Method,
+		Path:      r.URL.RequestURI(),
+		BodyHash:  hashBody(body),
+	}
+
+	r.Header.Set(SignatureKeyIDHeader, string(sr.KeyID))
+	r.Header.Set(SignatureTimestampHeader, strconv.FormatInt(sr.Timestamp.Unix(), 10))
+	r.Header.Set(SignatureNonceHeader, sr.Nonce)
+	r.Header.Set(SignatureHeader, sr.Sign(DeriveSigningKey(authKey)))
+	return nil
+}
+
+// signatureAuthenticator authenticates signed requests
+type signatureAuthenticator struct {
+	authSvc Service
+}
+
+// NewSignatureAuthenticator returns an authenticator that
+// authenticates requests signed with an auth key signing key
+func NewSignatureAuthenticator(authSvc Service) Authenticator {
+	return &signatureAuthenticator{authSvc: authSvc}
+}
+
+// Authenticate verifies the request signature and tracks the auth key usage
+func (a *signatureAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	r := c.Request()
+	signature := r.Header.Get(SignatureHeader)
+	if signature == "" {
+		return nil, ErrNoCredentials
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get(SignatureTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidSignature, "invalid timestamp")
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+
+	ctx := r.Context()
+	auth, err := a.authSvc.VerifySignedRequest(ctx, SignedRequest{
+		KeyID:     KeyID(r.Header.Get(SignatureKeyIDHeader)),
+		Timestamp: time.Unix(ts, 0),
+		Nonce:     r.Header.Get(SignatureNonceHeader),
+		Signature: signature,
+		Method:    r.Method,
+		Path:      r.URL.RequestURI(),
+		BodyHash:  hashBody(body),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// track auth key usage
+	err = a.authSvc.TouchAuthKey(ctx, auth.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "touch auth key")
+	}
+
+	return &Principal{
+		ID:     string(auth.ID),
+		Method: MethodSignature,
+		Scopes: auth.Scopes,
+	}, nil
+}
+
+// readBody reads the request body and replaces
+// it so that it can be read again by the handler
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+
+	if len(body) > maxSignedBodySize {
+		return nil, errors.New("request body too large")
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// hashBody returns the hex encoded sha256 hash of the body
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
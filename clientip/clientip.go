@@ -0,0 +1,97 @@
+package clientip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// Strategy is a client ip extraction strategy
+type Strategy string
+
+// List of client ip extraction strategies
+const (
+	// StrategyDirect uses the address of the connection,
+	// for servers that are directly exposed to the clients
+	StrategyDirect Strategy = "direct"
+	// StrategyXFF uses the nearest untrusted address
+	// of the X-Forwarded-For header
+	StrategyXFF Strategy = "xff"
+	// StrategyRealIP uses the X-Real-IP header
+	// when set by a trusted proxy
+	StrategyRealIP Strategy = "real-ip"
+)
+
+// ParseTrustedProxies parses a comma separated list of CIDRs,
+// a single ip address is parsed as a range of one address
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, errors.Wrap(ErrInvalidProxy, v)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidProxy, v)
+		}
+		proxies = append(proxies, ipNet)
+	}
+
+	return proxies, nil
+}
+
+// NewExtractor returns a client ip extractor, the forwarding headers are
+// only used when set by the trusted proxies so that clients can't spoof
+// their ip address. Loopback and private addresses are not trusted
+// unless they are in the trusted proxies.
+func NewExtractor(strategy Strategy, trustedProxies []*net.IPNet) (echo.IPExtractor, error) {
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		opts = append(opts, echo.TrustIPRange(proxy))
+	}
+
+	switch strategy {
+	case StrategyDirect:
+		if len(trustedProxies) > 0 {
+			return nil, ErrUnusedTrustedProxies
+		}
+
+		return echo.ExtractIPDirect(), nil
+	case StrategyXFF:
+		if len(trustedProxies) == 0 {
+			return nil, ErrNoTrustedProxies
+		}
+
+		return echo.ExtractIPFromXFFHeader(opts...), nil
+	case StrategyRealIP:
+		if len(trustedProxies) == 0 {
+			return nil, ErrNoTrustedProxies
+		}
+
+		return echo.ExtractIPFromRealIPHeader(opts...), nil
+	}
+
+	return nil, ErrInvalidStrategy
+}
@@ -0,0 +1,114 @@
+package clientip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/clientip"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := clientip.ParseTrustedProxies("10.0.0.0/8, 192.0.2.10,2001:db8::/32")
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "10.0.0.0/8", proxies[0].String())
+	assert.Equal(t, "192.0.2.10/32", proxies[1].String())
+	assert.Equal(t, "2001:db8::/32", proxies[2].String())
+
+	proxies, err = clientip.ParseTrustedProxies("")
+	require.NoError(t, err)
+	assert.Empty(t, proxies)
+
+	_, err = clientip.ParseTrustedProxies("10.0.0.0/33")
+	assert.ErrorIs(t, err, clientip.ErrInvalidProxy)
+
+	_, err = clientip.ParseTrustedProxies("proxy.local")
+	assert.ErrorIs(t, err, clientip.ErrInvalidProxy)
+}
+
+func TestNewExtractor(t *testing.T) {
+	proxies, err := clientip.ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	newRequest := func(remoteAddr string, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		extract, err := clientip.NewExtractor(clientip.StrategyDirect, nil)
+		require.NoError(t, err)
+
+		// forwarding headers are ignored
+		req := newRequest("203.0.113.5:1234", map[string]string{
+			echo.HeaderXForwardedFor: "198.51.100.1",
+			echo.HeaderXRealIP:       "198.51.100.1",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		_, err = clientip.NewExtractor(clientip.StrategyDirect, proxies)
+		assert.ErrorIs(t, err, clientip.ErrUnusedTrustedProxies)
+	})
+
+	t.Run("xff", func(t *testing.T) {
+		extract, err := clientip.NewExtractor(clientip.StrategyXFF, proxies)
+		require.NoError(t, err)
+
+		req := newRequest("10.0.0.2:1234", map[string]string{
+			echo.HeaderXForwardedFor: "203.0.113.5",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		// the spoofed entries before the nearest untrusted address are ignored
+		req = newRequest("10.0.0.2:1234", map[string]string{
+			echo.HeaderXForwardedFor: "198.51.100.1, 203.0.113.5",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		// the header is ignored when not sent by a trusted proxy
+		req = newRequest("203.0.113.5:1234", map[string]string{
+			echo.HeaderXForwardedFor: "198.51.100.1",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		// private addresses are not trusted by default
+		req = newRequest("192.168.1.2:1234", map[string]string{
+			echo.HeaderXForwardedFor: "198.51.100.1",
+		})
+		assert.Equal(t, "192.168.1.2", extract(req))
+
+		_, err = clientip.NewExtractor(clientip.StrategyXFF, nil)
+		assert.ErrorIs(t, err, clientip.ErrNoTrustedProxies)
+	})
+
+	t.Run("real ip", func(t *testing.T) {
+		extract, err := clientip.NewExtractor(clientip.StrategyRealIP, proxies)
+		require.NoError(t, err)
+
+		req := newRequest("10.0.0.2:1234", map[string]string{
+			echo.HeaderXRealIP: "203.0.113.5",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		// the header is ignored when not sent by a trusted proxy
+		req = newRequest("203.0.113.5:1234", map[string]string{
+			echo.HeaderXRealIP: "198.51.100.1",
+		})
+		assert.Equal(t, "203.0.113.5", extract(req))
+
+		_, err = clientip.NewExtractor(clientip.StrategyRealIP, nil)
+		assert.ErrorIs(t, err, clientip.ErrNoTrustedProxies)
+	})
+
+	_, err = clientip.NewExtractor("forwarded", proxies)
+	assert.ErrorIs(t, err, clientip.ErrInvalidStrategy)
+}
@@ -0,0 +1,11 @@
+package clientip
+
+import "github.com/pkg/errors"
+
+// List of client ip related errors
+var (
+	ErrInvalidStrategy      = errors.New("invalid ip extraction strategy")
+	ErrInvalidProxy         = errors.New("invalid trusted proxy")
+	ErrNoTrustedProxies     = errors.New("trusted proxies are required")
+	ErrUnusedTrustedProxies = errors.New("trusted proxies are not used with the direct strategy")
+)
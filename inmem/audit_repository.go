@@ -0,0 +1,110 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/audit"
+)
+
+// AuditRepository is an in-memory implementation of audit.Repository
+type AuditRepository struct {
+	db *memdb.MemDB
+}
+
+var _ audit.Repository = (*AuditRepository)(nil)
+
+// NewAuditRepository retuns a new audit repository
+func NewAuditRepository(db *memdb.MemDB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// CreateEvent inserts an audit event into the db
+func (repo *AuditRepository) CreateEvent(ctx context.Context, event *audit.Event) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	now := time.Now()
+	event.CreatedAt = &now
+
+	// insert a copy, objects in memdb must not be modified
+	newEvent := *event
+	err := txn.Insert(auditEventsTable, &newEvent)
+	if err != nil {
+		return errors.Wrap(err, "insert event")
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// ListEvents retrieves the audit events matching the query from the db
+func (repo *AuditRepository) ListEvents(ctx context.Context, query audit.ListQuery) ([]*audit.Event, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(auditEventsTable, "id")
+	if err != nil {
+		return nil, errors.Wrap(err, "get events iterator")
+	}
+
+	events := make([]*audit.Event, 0, 10)
+	for v := it.Next(); v != nil; v = it.Next() {
+		event, ok := v.(*audit.Event)
+		if !ok {
+			return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &audit.Event{})
+		}
+
+		if !matchEventQuery(event, query) {
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	// newest first
+	sort.Slice(events, func(i, j int) bool {
+		return lessEvent(events[j], events[i])
+	})
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
+	return events, nil
+}
+
+// matchEventQuery returns true if the event matches the list query
+func matchEventQuery(event *audit.Event, query audit.ListQuery) bool {
+	if query.Actor != "" && event.Actor != query.Actor {
+		return false
+	}
+
+	if query.Action != "" && event.Action != query.Action {
+		return false
+	}
+
+	if query.Target != "" && event.Target != query.Target {
+		return false
+	}
+
+	if query.After != nil {
+		after := &audit.Event{ID: query.After.ID, CreatedAt: &query.After.CreatedAt}
+		return lessEvent(event, after)
+	}
+
+	return true
+}
+
+// lessEvent orders events by creation timestamp and id
+func lessEvent(a, b *audit.Event) bool {
+	if !a.CreatedAt.Equal(*b.CreatedAt) {
+		return a.CreatedAt.Before(*b.CreatedAt)
+	}
+
+	return a.ID < b.ID
+}
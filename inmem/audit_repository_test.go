@@ -0,0 +1,11 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestAuditRepository(t *testing.T) {
+	storagetest.AuditRepository(t, storagetest.Inmem)
+}
@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/stevenferrer/invitesvc/authn"
@@ -22,41 +23,218 @@ func NewAuthRepository(db *memdb.MemDB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
-// CreateAuthKey inserts an auth key into the db
-func (repo *AuthRepository) CreateAuthKey(ctx context.Context, authKey authn.AuthKey) error {
+// CreateAuthKey inserts an auth record into the db
+func (repo *AuthRepository) CreateAuthKey(ctx context.Context, auth *authn.Auth) error {
 	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	// insert would overwrite existing auth key
+	v, err := txn.First(authsTable, "id", auth.ID)
+	if err != nil {
+		return errors.Wrap(err, "get auth key")
+	}
+
+	if v != nil {
+		return authn.ErrDuplicateAuthKey
+	}
+
+	err = insertAuthKey(txn, auth)
+	if err != nil {
+		return err
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// CreateInitialAuthKey inserts an auth record into the db only
+// when there are no auth records yet
+func (repo *AuthRepository) CreateInitialAuthKey(ctx context.Context, auth *authn.Auth) error {
+	// write transaction, only one can be active at a time
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	v, err := txn.First(authsTable, "id")
+	if err != nil {
+		return errors.Wrap(err, "get auth key")
+	}
+
+	if v != nil {
+		return authn.ErrAuthKeysExist
+	}
+
+	err = insertAuthKey(txn, auth)
+	if err != nil {
+		return err
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// insertAuthKey inserts a copy of the auth record
+func insertAuthKey(txn *memdb.Txn, auth *authn.Auth) error {
 	now := time.Now()
 	err := txn.Insert(authsTable, &authn.Auth{
-		Auth:      authKey,
-		CreatedAt: &now,
+		ID:         auth.ID,
+		Hash:       auth.Hash,
+		Salt:       auth.Salt,
+		Name:       auth.Name,
+		Owner:      auth.Owner,
+		Scopes:     auth.Scopes,
+		SigningKey: auth.SigningKey,
+		ExpiresAt:  auth.ExpiresAt,
+		CreatedAt:  &now,
+	})
+	return errors.Wrap(err, "insert auth key")
+}
+
+// GetAuthKey retrieves an auth record from the db
+func (repo *AuthRepository) GetAuthKey(ctx context.Context, id authn.KeyID) (*authn.Auth, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	v, err := txn.First(authsTable, "id", id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get auth key")
+	}
+
+	// auth key not found
+	if v == nil {
+		return nil, authn.ErrAuthKeyNotFound
+	}
+
+	auth, ok := v.(*authn.Auth)
+	if !ok {
+		return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &authn.Auth{})
+	}
+
+	return auth, nil
+}
+
+// ListAuthKeys retrieves all auth records from the db
+func (repo *AuthRepository) ListAuthKeys(ctx context.Context) ([]*authn.Auth, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(authsTable, "id")
+	if err != nil {
+		return nil, errors.Wrap(err, "get auth keys iterator")
+	}
+
+	auths := make([]*authn.Auth, 0, 10)
+	for v := it.Next(); v != nil; v = it.Next() {
+		auth, ok := v.(*authn.Auth)
+		if !ok {
+			return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &authn.Auth{})
+		}
+
+		auths = append(auths, auth)
+	}
+
+	sort.Slice(auths, func(i, j int) bool {
+		a, b := auths[i], auths[j]
+		if !a.CreatedAt.Equal(*b.CreatedAt) {
+			return a.CreatedAt.Before(*b.CreatedAt)
+		}
+
+		return a.ID < b.ID
 	})
+
+	return auths, nil
+}
+
+// SetAuthKeyRevoked sets an auth key to revoked
+func (repo *AuthRepository) SetAuthKeyRevoked(ctx context.Context, id authn.KeyID) error {
+	revokedAt := time.Now()
+	return repo.updateAuthKey(id, func(auth *authn.Auth) {
+		auth.RevokedAt = &revokedAt
+	})
+}
+
+// SetAuthKeyUsed sets the last used timestamp of an auth key to now
+func (repo *AuthRepository) SetAuthKeyUsed(ctx context.Context, id authn.KeyID) error {
+	lastUsedAt := time.Now()
+	return repo.updateAuthKey(id, func(auth *authn.Auth) {
+		auth.LastUsedAt = &lastUsedAt
+	})
+}
+
+// CreateNonce inserts a request nonce into the db, expired nonces are removed
+func (repo *AuthRepository) CreateNonce(ctx context.Context, nonce *authn.Nonce) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	// remove expired nonces
+	now := time.Now()
+	it, err := txn.Get(noncesTable, "id")
+	if err != nil {
+		return errors.Wrap(err, "get nonces iterator")
+	}
+
+	var expired []interface{}
+	for v := it.Next(); v != nil; v = it.Next() {
+		n, ok := v.(*authn.Nonce)
+		if ok && n.ExpiresAt.Before(now) {
+			expired = append(expired, v)
+		}
+	}
+
+	for _, v := range expired {
+		err = txn.Delete(noncesTable, v)
+		if err != nil {
+			return errors.Wrap(err, "delete expired nonce")
+		}
+	}
+
+	v, err := txn.First(noncesTable, "id", string(nonce.KeyID), nonce.Value)
+	if err != nil {
+		return errors.Wrap(err, "get nonce")
+	}
+
+	if v != nil {
+		return authn.ErrNonceReused
+	}
+
+	n := *nonce
+	err = txn.Insert(noncesTable, &n)
 	if err != nil {
-		return errors.Wrap(err, "insert auth key")
+		return errors.Wrap(err, "insert nonce")
 	}
 
 	txn.Commit()
 	return nil
 }
 
-// AuthKeyExists checks db if auth key exists
-func (repo *AuthRepository) AuthKeyExists(ctx context.Context, authKey authn.AuthKey) (bool, error) {
-	txn := repo.db.Txn(false)
+// updateAuthKey applies update to a copy of the auth record and saves it
+func (repo *AuthRepository) updateAuthKey(id authn.KeyID, update func(*authn.Auth)) error {
+	txn := repo.db.Txn(true)
 	defer txn.Abort()
 
-	v, err := txn.First(authsTable, "id", authKey)
+	v, err := txn.First(authsTable, "id", id)
 	if err != nil {
-		return false, err
+		return errors.Wrap(err, "get auth key")
 	}
 
 	// auth key not found
 	if v == nil {
-		return false, nil
+		return authn.ErrAuthKeyNotFound
 	}
 
-	_, ok := v.(*authn.Auth)
+	gotAuth, ok := v.(*authn.Auth)
 	if !ok {
-		return false, errors.Errorf("unexpected value type %T, expecting %T", v, &authn.Auth{})
+		return errors.Errorf("unexpected value type %T, expecting %T", v, &authn.Auth{})
 	}
 
-	return true, nil
+	// copy the auth record, objects in memdb must not be modified
+	newAuth := *gotAuth
+	update(&newAuth)
+
+	err = txn.Insert(authsTable, &newAuth)
+	if err != nil {
+		return errors.Wrap(err, "update auth key")
+	}
+
+	txn.Commit()
+	return nil
 }
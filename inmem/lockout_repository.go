@@ -0,0 +1,139 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/lockout"
+)
+
+// LockoutRepository is an in-memory implementation of lockout.Repository
+type LockoutRepository struct {
+	db *memdb.MemDB
+}
+
+var _ lockout.Repository = (*LockoutRepository)(nil)
+
+// NewLockoutRepository returns a new lockout repository
+func NewLockoutRepository(db *memdb.MemDB) *LockoutRepository {
+	return &LockoutRepository{db: db}
+}
+
+// GetLockout retrieves the lockout of a client from the db
+func (repo *LockoutRepository) GetLockout(ctx context.Context, client string) (*lockout.Lockout, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	v, err := txn.First(lockoutsTable, "id", client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get lockout")
+	}
+
+	if v == nil {
+		return nil, lockout.ErrLockoutNotFound
+	}
+
+	l := *(v.(*lockout.Lockout))
+	return &l, nil
+}
+
+// ListLockouts retrieves the lockouts from the db, last failed first
+func (repo *LockoutRepository) ListLockouts(ctx context.Context) ([]*lockout.Lockout, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(lockoutsTable, "id")
+	if err != nil {
+		return nil, errors.Wrap(err, "get lockouts iterator")
+	}
+
+	lockouts := make([]*lockout.Lockout, 0, 10)
+	for v := it.Next(); v != nil; v = it.Next() {
+		l := *(v.(*lockout.Lockout))
+		lockouts = append(lockouts, &l)
+	}
+
+	sort.SliceStable(lockouts, func(i, j int) bool {
+		return lockouts[i].FailedAt.After(*lockouts[j].FailedAt)
+	})
+
+	return lockouts, nil
+}
+
+// RecordFailure records a failed attempt of a client
+func (repo *LockoutRepository) RecordFailure(
+	ctx context.Context, client string, p lockout.Policy,
+) (*lockout.Lockout, error) {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	// remove the forgotten lockouts
+	now := time.Now()
+	it, err := txn.Get(lockoutsTable, "id")
+	if err != nil {
+		return nil, errors.Wrap(err, "get lockouts iterator")
+	}
+
+	var forgotten []interface{}
+	for v := it.Next(); v != nil; v = it.Next() {
+		l, ok := v.(*lockout.Lockout)
+		if ok && now.Sub(*l.FailedAt) > p.ResetAfter && !l.Locked(now) {
+			forgotten = append(forgotten, v)
+		}
+	}
+
+	for _, v := range forgotten {
+		err = txn.Delete(lockoutsTable, v)
+		if err != nil {
+			return nil, errors.Wrap(err, "delete forgotten lockout")
+		}
+	}
+
+	v, err := txn.First(lockoutsTable, "id", client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get lockout")
+	}
+
+	// update a copy, objects in memdb must not be modified
+	l := lockout.Lockout{Client: client}
+	if v != nil {
+		l = *(v.(*lockout.Lockout))
+	}
+
+	l.Fail(p, now)
+	newLockout := l
+	err = txn.Insert(lockoutsTable, &newLockout)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert lockout")
+	}
+
+	txn.Commit()
+	return &l, nil
+}
+
+// DeleteLockout removes the lockout of a client from the db
+func (repo *LockoutRepository) DeleteLockout(ctx context.Context, client string) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	v, err := txn.First(lockoutsTable, "id", client)
+	if err != nil {
+		return errors.Wrap(err, "get lockout")
+	}
+
+	if v == nil {
+		return lockout.ErrLockoutNotFound
+	}
+
+	err = txn.Delete(lockoutsTable, v)
+	if err != nil {
+		return errors.Wrap(err, "delete lockout")
+	}
+
+	txn.Commit()
+	return nil
+}
@@ -0,0 +1,11 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestLockoutRepository(t *testing.T) {
+	storagetest.LockoutRepository(t, storagetest.Inmem)
+}
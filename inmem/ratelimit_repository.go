@@ -0,0 +1,82 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/ratelimit"
+)
+
+// RateLimitRepository is an in-memory implementation of ratelimit.Repository
+type RateLimitRepository struct {
+	db *memdb.MemDB
+}
+
+var _ ratelimit.Repository = (*RateLimitRepository)(nil)
+
+// NewRateLimitRepository returns a new rate limit repository
+func NewRateLimitRepository(db *memdb.MemDB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// TakeToken takes a token from the bucket of the key
+func (repo *RateLimitRepository) TakeToken(ctx context.Context, key string, cfg ratelimit.Config) (bool, error) {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	v, err := txn.First(rateLimitsTable, "id", key)
+	if err != nil {
+		return false, errors.Wrap(err, "get bucket")
+	}
+
+	// take from a copy, objects in memdb must not be modified
+	bucket := ratelimit.Bucket{Key: key}
+	if v != nil {
+		bucket = *(v.(*ratelimit.Bucket))
+	}
+
+	if !bucket.Take(cfg, time.Now()) {
+		return false, nil
+	}
+
+	err = txn.Insert(rateLimitsTable, &bucket)
+	if err != nil {
+		return false, errors.Wrap(err, "insert bucket")
+	}
+
+	txn.Commit()
+	return true, nil
+}
+
+// DeleteExpiredBuckets removes the expired buckets from the db
+func (repo *RateLimitRepository) DeleteExpiredBuckets(ctx context.Context) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	now := time.Now()
+	it, err := txn.Get(rateLimitsTable, "id")
+	if err != nil {
+		return errors.Wrap(err, "get buckets iterator")
+	}
+
+	var expired []interface{}
+	for v := it.Next(); v != nil; v = it.Next() {
+		b, ok := v.(*ratelimit.Bucket)
+		if ok && !now.Before(b.ExpiresAt) {
+			expired = append(expired, v)
+		}
+	}
+
+	for _, v := range expired {
+		err = txn.Delete(rateLimitsTable, v)
+		if err != nil {
+			return errors.Wrap(err, "delete expired bucket")
+		}
+	}
+
+	txn.Commit()
+	return nil
+}
@@ -0,0 +1,11 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestRateLimitRepository(t *testing.T) {
+	storagetest.RateLimitRepository(t, storagetest.Inmem)
+}
@@ -10,8 +10,14 @@ import (
 )
 
 const (
-	tokensTable = "tokens"
-	authsTable  = "authns"
+	tokensTable      = "tokens"
+	redemptionsTable = "redemptions"
+	campaignsTable   = "campaigns"
+	authsTable       = "authns"
+	noncesTable      = "nonces"
+	auditEventsTable = "audit_events"
+	rateLimitsTable  = "rate_limits"
+	lockoutsTable    = "lockouts"
 )
 
 // Schema returns the memdb schema
@@ -28,13 +34,82 @@ func Schema() *memdb.DBSchema {
 					},
 				},
 			},
+			redemptionsTable: {
+				Name: redemptionsTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID"},
+					},
+					"token": {
+						Name:    "token",
+						Indexer: &memdb.StringFieldIndex{Field: "Token"},
+					},
+				},
+			},
+			campaignsTable: {
+				Name: campaignsTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID"},
+					},
+				},
+			},
 			authsTable: {
 				Name: authsTable,
 				Indexes: map[string]*memdb.IndexSchema{
 					"id": {
 						Name:    "id",
 						Unique:  true,
-						Indexer: authKeyIndexer{},
+						Indexer: authKeyIDIndexer{},
+					},
+				},
+			},
+			auditEventsTable: {
+				Name: auditEventsTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID"},
+					},
+				},
+			},
+			noncesTable: {
+				Name: noncesTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "KeyID"},
+								&memdb.StringFieldIndex{Field: "Value"},
+							},
+						},
+					},
+				},
+			},
+			rateLimitsTable: {
+				Name: rateLimitsTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Key"},
+					},
+				},
+			},
+			lockoutsTable: {
+				Name: lockoutsTable,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Client"},
 					},
 				},
 			},
@@ -71,31 +146,31 @@ func (tokenIDIndexer) FromObject(raw interface{}) (bool, []byte, error) {
 	return true, append([]byte(t.ID), 0), nil
 }
 
-// authKeyIndexer implements memdb.Indexer and memdb.SingleIndexer
-type authKeyIndexer struct{}
+// authKeyIDIndexer implements memdb.Indexer and memdb.SingleIndexer
+type authKeyIDIndexer struct{}
 
-func (authKeyIndexer) FromArgs(args ...interface{}) ([]byte, error) {
+func (authKeyIDIndexer) FromArgs(args ...interface{}) ([]byte, error) {
 	if len(args) != 1 {
 		return nil, fmt.Errorf("wrong number of args %d, expected 1", len(args))
 	}
 
-	authKey, ok := args[0].(authn.AuthKey)
+	keyID, ok := args[0].(authn.KeyID)
 	if !ok {
 		return nil, fmt.Errorf("wrong type for arg %T, expected string", args[0])
 	}
 
-	return append([]byte(authKey), 0), nil
+	return append([]byte(keyID), 0), nil
 }
 
-func (authKeyIndexer) FromObject(raw interface{}) (bool, []byte, error) {
+func (authKeyIDIndexer) FromObject(raw interface{}) (bool, []byte, error) {
 	a, ok := raw.(*authn.Auth)
 	if !ok {
 		return false, nil, fmt.Errorf("wrong type for arg %T, expected MyStruct", raw)
 	}
 
-	if a.Auth == authn.AuthKey("") {
+	if a.ID == authn.NilKeyID {
 		return false, nil, nil
 	}
 
-	return true, append([]byte(a.Auth), 0), nil
+	return true, append([]byte(a.ID), 0), nil
 }
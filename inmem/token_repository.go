@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/go-memdb"
@@ -23,19 +24,49 @@ func NewTokenRepository(db *memdb.MemDB) *TokenRepository {
 }
 
 // CreateToken creates a new token and saves it to database
-func (repo *TokenRepository) CreateToken(ctx context.Context, id token.ID) error {
+func (repo *TokenRepository) CreateToken(ctx context.Context, tk *token.Token) error {
+	return repo.CreateTokens(ctx, []*token.Token{tk})
+}
+
+// CreateTokens creates new tokens in a single transaction
+func (repo *TokenRepository) CreateTokens(ctx context.Context, tks []*token.Token) error {
 	// write transactin
 	txn := repo.db.Txn(true)
-	now := time.Now()
-	// insert token
-	err := txn.Insert(tokensTable, &token.Token{
-		ID:         id,
-		CreatedAt:  &now,
-		RedeemedAt: nil,
-		Disabled:   false,
-	})
+	defer txn.Abort()
+
+	err := incrCampaignTokens(txn, tks)
 	if err != nil {
-		return errors.Wrap(err, "insert token")
+		return err
+	}
+
+	now := time.Now()
+	for _, tk := range tks {
+		// insert would overwrite existing token
+		v, err := txn.First(tokensTable, "id", tk.ID)
+		if err != nil {
+			return errors.Wrap(err, "get token")
+		}
+
+		if v != nil {
+			return token.ErrDuplicateToken
+		}
+
+		// insert token
+		err = txn.Insert(tokensTable, &token.Token{
+			ID:             tk.ID,
+			MaxRedemptions: tk.MaxRedemptions,
+			ExpiresAt:      tk.ExpiresAt,
+			NotBefore:      tk.NotBefore,
+			Metadata:       tk.Metadata,
+			Labels:         tk.Labels,
+			CampaignID:     tk.CampaignID,
+			CreatedAt:      &now,
+			RedeemedAt:     nil,
+			Disabled:       false,
+		})
+		if err != nil {
+			return errors.Wrap(err, "insert token")
+		}
 	}
 
 	// commit txn
@@ -43,6 +74,55 @@ func (repo *TokenRepository) CreateToken(ctx context.Context, id token.ID) error
 	return nil
 }
 
+// incrCampaignTokens increments the token count of the campaigns of the tokens
+func incrCampaignTokens(txn *memdb.Txn, tks []*token.Token) error {
+	counts := make(map[token.CampaignID]int)
+	for _, tk := range tks {
+		if tk.CampaignID != token.NilCampaignID {
+			counts[tk.CampaignID]++
+		}
+	}
+
+	for id, n := range counts {
+		c, err := getCampaign(txn, id)
+		if err != nil {
+			return err
+		}
+
+		if c.MaxTokens > 0 && c.Tokens+n > c.MaxTokens {
+			return token.ErrCampaignFull
+		}
+
+		// copy the campaign, objects in memdb must not be modified
+		now := time.Now()
+		newC := *c
+		newC.Tokens += n
+		newC.UpdatedAt = &now
+		err = txn.Insert(campaignsTable, &newC)
+		if err != nil {
+			return errors.Wrap(err, "update campaign")
+		}
+	}
+
+	return nil
+}
+
+// withCampaign returns a copy of the token with the campaign state
+func withCampaign(txn *memdb.Txn, tk *token.Token) (*token.Token, error) {
+	if tk.CampaignID == token.NilCampaignID {
+		return tk, nil
+	}
+
+	c, err := getCampaign(txn, tk.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	newTk := *tk
+	newTk.CampaignDisabled = c.Disabled
+	return &newTk, nil
+}
+
 // GetToken retrieves a token from the database
 func (repo *TokenRepository) GetToken(ctx context.Context, id token.ID) (*token.Token, error) {
 	// read-ony transaction
@@ -65,11 +145,11 @@ func (repo *TokenRepository) GetToken(ctx context.Context, id token.ID) (*token.
 		return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &token.Token{})
 	}
 
-	return t, nil
+	return withCampaign(txn, t)
 }
 
-// ListToken retrieves a list of tokens from the database
-func (repo *TokenRepository) ListTokens(ctx context.Context) ([]*token.Token, error) {
+// ListToken retrieves a list of tokens matching the query from the database
+func (repo *TokenRepository) ListTokens(ctx context.Context, query token.ListQuery) ([]*token.Token, error) {
 	txn := repo.db.Txn(false)
 	defer txn.Abort()
 
@@ -85,28 +165,146 @@ func (repo *TokenRepository) ListTokens(ctx context.Context) ([]*token.Token, er
 			return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &token.Token{})
 		}
 
+		t, err = withCampaign(txn, t)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matchQuery(t, query) {
+			continue
+		}
+
 		tokens = append(tokens, t)
 	}
 
+	desc := query.Sort == token.SortDesc
+	sort.Slice(tokens, func(i, j int) bool {
+		if desc {
+			i, j = j, i
+		}
+		return lessToken(tokens[i], tokens[j])
+	})
+
+	if query.Limit > 0 && len(tokens) > query.Limit {
+		tokens = tokens[:query.Limit]
+	}
+
 	return tokens, nil
 }
 
+// matchQuery returns true if the token matches the list query
+func matchQuery(t *token.Token, query token.ListQuery) bool {
+	if query.Status != "" && t.Status() != query.Status {
+		return false
+	}
+
+	if query.CreatedAfter != nil && t.CreatedAt.Before(*query.CreatedAfter) {
+		return false
+	}
+
+	if query.CreatedBefore != nil && !t.CreatedAt.Before(*query.CreatedBefore) {
+		return false
+	}
+
+	if !t.HasLabels(query.Labels...) {
+		return false
+	}
+
+	if query.Campaign != token.NilCampaignID && t.CampaignID != query.Campaign {
+		return false
+	}
+
+	if query.After != nil {
+		after := &token.Token{ID: query.After.ID, CreatedAt: &query.After.CreatedAt}
+		if query.Sort == token.SortDesc {
+			return lessToken(t, after)
+		}
+
+		return lessToken(after, t)
+	}
+
+	return true
+}
+
+// lessToken orders tokens by creation timestamp and id
+func lessToken(a, b *token.Token) bool {
+	if !a.CreatedAt.Equal(*b.CreatedAt) {
+		return a.CreatedAt.Before(*b.CreatedAt)
+	}
+
+	return a.ID < b.ID
+}
+
+// CountTokens retrieves the number of tokens per status from the database
+func (repo *TokenRepository) CountTokens(ctx context.Context) (map[token.Status]int, error) {
+	tokens, err := repo.ListTokens(ctx, token.ListQuery{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list tokens")
+	}
+
+	counts := make(map[token.Status]int, len(token.Statuses))
+	for _, tk := range tokens {
+		counts[tk.Status()]++
+	}
+
+	return counts, nil
+}
+
 // SetTokenDisabled sets a token to disabled
 func (repo *TokenRepository) SetTokenDisabled(ctx context.Context, id token.ID) error {
-	gotTk, err := repo.GetToken(ctx, id)
+	return repo.updateToken(id, func(tk *token.Token) {
+		tk.Disabled = true
+	})
+}
+
+// SetTokenEnabled sets a token to not disabled
+func (repo *TokenRepository) SetTokenEnabled(ctx context.Context, id token.ID) error {
+	return repo.updateToken(id, func(tk *token.Token) {
+		tk.Disabled = false
+	})
+}
+
+// SetTokenExpiration sets the token expiration
+func (repo *TokenRepository) SetTokenExpiration(ctx context.Context, id token.ID, expiresAt *time.Time) error {
+	return repo.updateToken(id, func(tk *token.Token) {
+		tk.ExpiresAt = expiresAt
+	})
+}
+
+// SetTokenRevoked sets a token to revoked
+func (repo *TokenRepository) SetTokenRevoked(ctx context.Context, id token.ID, reason string) error {
+	revokedAt := time.Now()
+	return repo.updateToken(id, func(tk *token.Token) {
+		tk.RevokedAt = &revokedAt
+		tk.RevokeReason = reason
+	})
+}
+
+// updateToken applies update to a copy of the token and saves it
+func (repo *TokenRepository) updateToken(id token.ID, update func(*token.Token)) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	v, err := txn.First(tokensTable, "id", id)
 	if err != nil {
 		return errors.Wrap(err, "get token")
 	}
 
-	newTk := &token.Token{
-		ID:         gotTk.ID,
-		Disabled:   true,
-		RedeemedAt: gotTk.RedeemedAt,
-		CreatedAt:  gotTk.CreatedAt,
+	// token not found
+	if v == nil {
+		return token.ErrTokenNotFound
 	}
 
-	txn := repo.db.Txn(true)
-	err = txn.Insert(tokensTable, newTk)
+	gotTk, ok := v.(*token.Token)
+	if !ok {
+		return errors.Errorf("unexpected value type %T, expecting %T", v, &token.Token{})
+	}
+
+	// copy the token, objects in memdb must not be modified
+	newTk := *gotTk
+	update(&newTk)
+
+	err = txn.Insert(tokensTable, &newTk)
 	if err != nil {
 		return errors.Wrap(err, "update token")
 	}
@@ -115,25 +313,239 @@ func (repo *TokenRepository) SetTokenDisabled(ctx context.Context, id token.ID)
 	return nil
 }
 
-// SetTokenRedeemed sets a token to redeemed
-func (repo *TokenRepository) SetTokenRedeemed(ctx context.Context, id token.ID) error {
-	gotTk, err := repo.GetToken(ctx, id)
+// RedeemToken sets a token to redeemed and inserts the redemption
+// record. Validation and update are done within a single write
+// transaction so that concurrent redemptions cannot exceed the max
+// redemptions.
+func (repo *TokenRepository) RedeemToken(ctx context.Context, r *token.Redemption) error {
+	// write transaction, only one can be active at a time
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	v, err := txn.First(tokensTable, "id", r.Token)
 	if err != nil {
 		return errors.Wrap(err, "get token")
 	}
 
+	// token not found
+	if v == nil {
+		return token.ErrTokenNotFound
+	}
+
+	gotTk, ok := v.(*token.Token)
+	if !ok {
+		return errors.Errorf("unexpected value type %T, expecting %T", v, &token.Token{})
+	}
+
+	// validate token with the campaign state
+	validTk, err := withCampaign(txn, gotTk)
+	if err != nil {
+		return err
+	}
+
+	err = validTk.Validate()
+	if err != nil {
+		return err
+	}
+
+	// copy the token, objects in memdb must not be modified
 	redeemedAt := time.Now()
-	newTk := &token.Token{
-		ID:         gotTk.ID,
-		Disabled:   true,
-		RedeemedAt: &redeemedAt,
-		CreatedAt:  gotTk.CreatedAt,
+	newTk := *gotTk
+	newTk.Redemptions++
+	newTk.RedeemedAt = &redeemedAt
+
+	err = txn.Insert(tokensTable, &newTk)
+	if err != nil {
+		return errors.Wrap(err, "update token")
+	}
+
+	newR := *r
+	newR.CreatedAt = &redeemedAt
+	err = txn.Insert(redemptionsTable, &newR)
+	if err != nil {
+		return errors.Wrap(err, "insert redemption")
 	}
 
+	txn.Commit()
+	r.CreatedAt = &redeemedAt
+	return nil
+}
+
+// ListRedemptions retrieves the redemption records of a token
+func (repo *TokenRepository) ListRedemptions(ctx context.Context, id token.ID) ([]*token.Redemption, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(redemptionsTable, "token", string(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "get redemptions iterator")
+	}
+
+	redemptions := make([]*token.Redemption, 0, 10)
+	for v := it.Next(); v != nil; v = it.Next() {
+		r, ok := v.(*token.Redemption)
+		if !ok {
+			return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &token.Redemption{})
+		}
+
+		redemptions = append(redemptions, r)
+	}
+
+	sort.Slice(redemptions, func(i, j int) bool {
+		return redemptions[i].CreatedAt.Before(*redemptions[j].CreatedAt)
+	})
+
+	return redemptions, nil
+}
+
+// CreateCampaign creates a new campaign and saves it to database
+func (repo *TokenRepository) CreateCampaign(ctx context.Context, c *token.Campaign) error {
 	txn := repo.db.Txn(true)
-	err = txn.Insert(tokensTable, newTk)
+	defer txn.Abort()
+
+	now := time.Now()
+	c.CreatedAt, c.UpdatedAt = &now, &now
+
+	// insert a copy, objects in memdb must not be modified
+	newC := *c
+	err := txn.Insert(campaignsTable, &newC)
 	if err != nil {
-		return errors.Wrap(err, "update token")
+		return errors.Wrap(err, "insert campaign")
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// GetCampaign retrieves a campaign from the database
+func (repo *TokenRepository) GetCampaign(ctx context.Context, id token.CampaignID) (*token.Campaign, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	c, err := getCampaign(txn, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// return a copy, objects in memdb must not be modified
+	gotC := *c
+	return &gotC, nil
+}
+
+// getCampaign retrieves a campaign within the transaction
+func getCampaign(txn *memdb.Txn, id token.CampaignID) (*token.Campaign, error) {
+	v, err := txn.First(campaignsTable, "id", string(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "get campaign")
+	}
+
+	// campaign not found
+	if v == nil {
+		return nil, token.ErrCampaignNotFound
+	}
+
+	c, ok := v.(*token.Campaign)
+	if !ok {
+		return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &token.Campaign{})
+	}
+
+	return c, nil
+}
+
+// ListCampaigns retrieves all campaigns from the database
+func (repo *TokenRepository) ListCampaigns(ctx context.Context) ([]*token.Campaign, error) {
+	txn := repo.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(campaignsTable, "id")
+	if err != nil {
+		return nil, errors.Wrap(err, "get campaigns iterator")
+	}
+
+	campaigns := make([]*token.Campaign, 0, 10)
+	for v := it.Next(); v != nil; v = it.Next() {
+		c, ok := v.(*token.Campaign)
+		if !ok {
+			return nil, errors.Errorf("unexpected value type %T, expecting %T", v, &token.Campaign{})
+		}
+
+		gotC := *c
+		campaigns = append(campaigns, &gotC)
+	}
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		a, b := campaigns[i], campaigns[j]
+		if !a.CreatedAt.Equal(*b.CreatedAt) {
+			return a.CreatedAt.Before(*b.CreatedAt)
+		}
+
+		return a.ID < b.ID
+	})
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates the campaign settings
+func (repo *TokenRepository) UpdateCampaign(ctx context.Context, c *token.Campaign) error {
+	return repo.updateCampaign(c.ID, func(newC *token.Campaign) {
+		newC.Name = c.Name
+		newC.TTL = c.TTL
+		newC.MaxRedemptions = c.MaxRedemptions
+		newC.StartsAt = c.StartsAt
+		newC.EndsAt = c.EndsAt
+		newC.MaxTokens = c.MaxTokens
+	})
+}
+
+// SetCampaignDisabled sets a campaign to disabled or not disabled
+func (repo *TokenRepository) SetCampaignDisabled(ctx context.Context, id token.CampaignID, disabled bool) error {
+	return repo.updateCampaign(id, func(c *token.Campaign) {
+		c.Disabled = disabled
+	})
+}
+
+// updateCampaign applies update to a copy of the campaign and saves it
+func (repo *TokenRepository) updateCampaign(id token.CampaignID, update func(*token.Campaign)) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	gotC, err := getCampaign(txn, id)
+	if err != nil {
+		return err
+	}
+
+	// copy the campaign, objects in memdb must not be modified
+	now := time.Now()
+	newC := *gotC
+	update(&newC)
+	newC.UpdatedAt = &now
+
+	err = txn.Insert(campaignsTable, &newC)
+	if err != nil {
+		return errors.Wrap(err, "update campaign")
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteCampaign deletes a campaign without tokens from the database
+func (repo *TokenRepository) DeleteCampaign(ctx context.Context, id token.CampaignID) error {
+	txn := repo.db.Txn(true)
+	defer txn.Abort()
+
+	c, err := getCampaign(txn, id)
+	if err != nil {
+		return err
+	}
+
+	if c.Tokens > 0 {
+		return token.ErrCampaignHasTokens
+	}
+
+	err = txn.Delete(campaignsTable, c)
+	if err != nil {
+		return errors.Wrap(err, "delete campaign")
 	}
 
 	txn.Commit()
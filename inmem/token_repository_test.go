@@ -9,94 +9,46 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/stevenferrer/invitesvc/inmem"
+	"github.com/stevenferrer/invitesvc/storagetest"
 	"github.com/stevenferrer/invitesvc/token"
 )
 
 func TestTokenRepository(t *testing.T) {
+	storagetest.TokenRepository(t, storagetest.Inmem)
+}
+
+func TestCampaignSnapshots(t *testing.T) {
 	db, err := memdb.NewMemDB(inmem.Schema())
 	require.NoError(t, err)
 
 	ctx := context.TODO()
 	tokenRepo := inmem.NewTokenRepository(db)
+	tokenSvc := token.NewService(tokenRepo)
 
-	t.Run("create and retrieve token", func(t *testing.T) {
-		tokenID, err := token.NewID()
-		require.NoError(t, err)
-
-		err = tokenRepo.CreateToken(ctx, tokenID)
-		require.NoError(t, err)
-
-		gotToken, err := tokenRepo.GetToken(ctx, tokenID)
-		require.NoError(t, err)
-
-		assert.Equal(t, tokenID, gotToken.ID)
-		assert.NotNil(t, gotToken.CreatedAt)
-		assert.Nil(t, gotToken.RedeemedAt)
-
-		t.Run("token not found", func(t *testing.T) {
-			tokenID, err = token.NewID()
-			require.NoError(t, err)
-
-			gotToken, err = tokenRepo.GetToken(ctx, tokenID)
-			assert.ErrorIs(t, err, token.ErrTokenNotFound)
-			assert.Nil(t, gotToken)
-		})
-	})
-
-	t.Run("list tokens", func(t *testing.T) {
-		gotTokens, err := tokenRepo.ListTokens(ctx)
-		require.NoError(t, err)
-		assert.Len(t, gotTokens, 1)
-
-	})
-
-	t.Run("set token to disabled", func(t *testing.T) {
-		gotTokens, err := tokenRepo.ListTokens(ctx)
-		require.NoError(t, err)
-		assert.Len(t, gotTokens, 1)
-
-		tk := gotTokens[0]
-
-		// set to disabled
-		err = tokenRepo.SetTokenDisabled(ctx, tk.ID)
-		require.NoError(t, err)
-
-		// verify update
-		gotTk, err := tokenRepo.GetToken(ctx, tk.ID)
-		require.NoError(t, err)
-		assert.True(t, gotTk.Disabled)
-
-		t.Run("token not found", func(t *testing.T) {
-			tokenID, err := token.NewID()
-			require.NoError(t, err)
-
-			err = tokenRepo.SetTokenDisabled(ctx, tokenID)
-			assert.ErrorIs(t, err, token.ErrTokenNotFound)
-		})
-	})
-
-	t.Run("set token to redeemed", func(t *testing.T) {
-		gotTokens, err := tokenRepo.ListTokens(ctx)
-		require.NoError(t, err)
-		assert.Len(t, gotTokens, 1)
+	campaign, err := tokenSvc.CreateCampaign(ctx, token.CampaignParams{Name: "a"})
+	require.NoError(t, err)
 
-		tk := gotTokens[0]
+	// an older read is not changed by updates
+	oldC, err := tokenRepo.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
 
-		// set to redeemed
-		err = tokenRepo.SetTokenRedeemed(ctx, tk.ID)
-		require.NoError(t, err)
+	_, err = tokenSvc.UpdateCampaign(ctx, campaign.ID, token.CampaignParams{Name: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "a", oldC.Name)
 
-		// verify update
-		gotTk, err := tokenRepo.GetToken(ctx, tk.ID)
-		require.NoError(t, err)
-		assert.NotNil(t, gotTk.RedeemedAt)
+	gotC, err := tokenRepo.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", gotC.Name)
 
-		t.Run("token not found", func(t *testing.T) {
-			tokenID, err := token.NewID()
-			require.NoError(t, err)
+	// modifying a read campaign doesn't change the stored campaign
+	gotC.Name = "c"
+	campaigns, err := tokenRepo.ListCampaigns(ctx)
+	require.NoError(t, err)
+	require.Len(t, campaigns, 1)
+	assert.Equal(t, "b", campaigns[0].Name)
 
-			err = tokenRepo.SetTokenDisabled(ctx, tokenID)
-			assert.ErrorIs(t, err, token.ErrTokenNotFound)
-		})
-	})
+	campaigns[0].Name = "d"
+	gotC, err = tokenRepo.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", gotC.Name)
 }
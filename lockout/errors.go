@@ -0,0 +1,10 @@
+package lockout
+
+import "github.com/pkg/errors"
+
+// List of lockout related errors
+var (
+	ErrLockoutNotFound = errors.New("lockout not found")
+	ErrClientLocked    = errors.New("too many failed attempts")
+	ErrInvalidPolicy   = errors.New("invalid lockout policy")
+)
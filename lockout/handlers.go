@@ -0,0 +1,61 @@
+package lockout
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// lockoutResponse is a lockout response
+type lockoutResponse struct {
+	*Lockout
+	Locked bool `json:"locked"`
+}
+
+// listLockoutsResponse is the list lockouts response
+type listLockoutsResponse struct {
+	Lockouts []lockoutResponse `json:"lockouts"`
+}
+
+// NewListLockoutsHandler returns a handler which is used for listing lockouts
+func NewListLockoutsHandler(lockoutSvc Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		lockouts, err := lockoutSvc.ListLockouts(c.Request().Context())
+		if err != nil {
+			return errors.Wrap(err, "list lockouts")
+		}
+
+		now := time.Now()
+		resp := listLockoutsResponse{
+			Lockouts: make([]lockoutResponse, 0, len(lockouts)),
+		}
+		for _, lockout := range lockouts {
+			resp.Lockouts = append(resp.Lockouts, lockoutResponse{
+				Lockout: lockout,
+				Locked:  lockout.Locked(now),
+			})
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// NewClearLockoutHandler returns a handler which is used for clearing a lockout
+func NewClearLockoutHandler(lockoutSvc Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := lockoutSvc.ClearLockout(c.Request().Context(), c.Param("client"))
+		if err != nil {
+			if errors.Is(err, ErrLockoutNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "lockout not found")
+			}
+
+			return errors.Wrap(err, "clear lockout")
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"message": "lockout successfully cleared.",
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package lockout
+
+import "time"
+
+// Policy is a failed attempts lockout policy, the client is locked out
+// after MaxFailures failed attempts and every further failed attempt
+// doubles the lockout duration up to MaxDuration
+type Policy struct {
+	// MaxFailures is the number of failed attempts before the client is locked out
+	MaxFailures int
+	// Duration is the duration of the first lockout
+	Duration time.Duration
+	// MaxDuration is the max duration of a lockout
+	MaxDuration time.Duration
+	// ResetAfter is the duration without failed attempts
+	// after which the failed attempts are forgotten
+	ResetAfter time.Duration
+}
+
+// DefaultPolicy is the default lockout policy
+var DefaultPolicy = Policy{
+	MaxFailures: 10,
+	Duration:    time.Minute,
+	MaxDuration: 24 * time.Hour,
+	ResetAfter:  24 * time.Hour,
+}
+
+// Validate validates the policy, the failed attempts must not
+// be forgotten before the longest lockout is over
+func (p Policy) Validate() error {
+	if p.MaxFailures < 1 || p.Duration <= 0 ||
+		p.MaxDuration < p.Duration || p.ResetAfter < p.MaxDuration {
+		return ErrInvalidPolicy
+	}
+
+	return nil
+}
+
+// Lockout is the failed attempts of a client
+type Lockout struct {
+	// Client is the client identity, e.g. the ip address
+	Client string `json:"client"`
+	// Failures is the number of failed attempts
+	Failures int `json:"failures"`
+	// LockedUntil is the end of the last lockout, nil if never locked out
+	LockedUntil *time.Time `json:"lockedUntil"`
+	// FailedAt is the timestamp of the last failed attempt
+	FailedAt *time.Time `json:"failedAt"`
+}
+
+// Locked returns true if the client is locked out at now
+func (l *Lockout) Locked(now time.Time) bool {
+	return l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
+
+// RetryAfter returns the remaining lockout duration at now
+func (l *Lockout) RetryAfter(now time.Time) time.Duration {
+	if !l.Locked(now) {
+		return 0
+	}
+
+	return l.LockedUntil.Sub(now)
+}
+
+// Fail records a failed attempt at now and locks out the client
+// when the number of failed attempts reaches the policy limit
+func (l *Lockout) Fail(p Policy, now time.Time) {
+	if l.FailedAt != nil && now.Sub(*l.FailedAt) >= p.ResetAfter {
+		l.Failures = 0
+	}
+
+	l.Failures++
+	l.FailedAt = &now
+	if l.Failures < p.MaxFailures {
+		return
+	}
+
+	d := p.Duration
+	for i := p.MaxFailures; i < l.Failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+
+	lockedUntil := now.Add(d)
+	l.LockedUntil = &lockedUntil
+}
@@ -0,0 +1,71 @@
+package lockout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stevenferrer/invitesvc/lockout"
+)
+
+func TestPolicy(t *testing.T) {
+	assert.NoError(t, lockout.DefaultPolicy.Validate())
+
+	p := lockout.DefaultPolicy
+	p.MaxFailures = 0
+	assert.ErrorIs(t, p.Validate(), lockout.ErrInvalidPolicy)
+
+	p = lockout.DefaultPolicy
+	p.MaxDuration = p.Duration / 2
+	assert.ErrorIs(t, p.Validate(), lockout.ErrInvalidPolicy)
+
+	p = lockout.DefaultPolicy
+	p.ResetAfter = p.MaxDuration / 2
+	assert.ErrorIs(t, p.Validate(), lockout.ErrInvalidPolicy)
+}
+
+func TestLockout(t *testing.T) {
+	p := lockout.Policy{
+		MaxFailures: 3,
+		Duration:    time.Minute,
+		MaxDuration: 5 * time.Minute,
+		ResetAfter:  time.Hour,
+	}
+	now := time.Now()
+
+	l := &lockout.Lockout{Client: "192.0.2.1"}
+	l.Fail(p, now)
+	l.Fail(p, now)
+	assert.Equal(t, 2, l.Failures)
+	assert.False(t, l.Locked(now))
+	assert.Equal(t, time.Duration(0), l.RetryAfter(now))
+
+	l.Fail(p, now)
+	assert.True(t, l.Locked(now))
+	assert.Equal(t, time.Minute, l.RetryAfter(now))
+
+	t.Run("escalating lockouts", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		assert.False(t, l.Locked(now))
+
+		l.Fail(p, now)
+		assert.Equal(t, 2*time.Minute, l.RetryAfter(now))
+
+		now = now.Add(2 * time.Minute)
+		l.Fail(p, now)
+		assert.Equal(t, 4*time.Minute, l.RetryAfter(now))
+
+		// capped at max duration
+		now = now.Add(4 * time.Minute)
+		l.Fail(p, now)
+		assert.Equal(t, 5*time.Minute, l.RetryAfter(now))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		now = now.Add(p.ResetAfter)
+		l.Fail(p, now)
+		assert.Equal(t, 1, l.Failures)
+		assert.False(t, l.Locked(now))
+	})
+}
@@ -0,0 +1,18 @@
+package lockout
+
+import "context"
+
+// Repository is a lockout repository
+type Repository interface {
+	// GetLockout retrieves the lockout of a client, ErrLockoutNotFound
+	// is returned if the client has no failed attempts
+	GetLockout(ctx context.Context, client string) (*Lockout, error)
+	// ListLockouts retrieves the lockouts of all clients
+	ListLockouts(context.Context) ([]*Lockout, error)
+	// RecordFailure atomically records a failed attempt of a client
+	// with the policy and returns the updated lockout, the forgotten
+	// lockouts are removed
+	RecordFailure(ctx context.Context, client string, p Policy) (*Lockout, error)
+	// DeleteLockout removes the lockout of a client
+	DeleteLockout(ctx context.Context, client string) error
+}
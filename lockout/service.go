@@ -0,0 +1,90 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Service is a lockout service
+type Service interface {
+	// Check returns ErrClientLocked and the remaining
+	// lockout duration if the client is locked out
+	Check(ctx context.Context, client string) (time.Duration, error)
+	// RecordFailure records a failed attempt of a client
+	RecordFailure(ctx context.Context, client string) (*Lockout, error)
+	// ListLockouts retrieves the lockouts of all clients
+	ListLockouts(context.Context) ([]*Lockout, error)
+	// ClearLockout forgets the failed attempts of a client
+	ClearLockout(ctx context.Context, client string) error
+}
+
+// lockoutService implements lockout service
+type lockoutService struct {
+	repo   Repository
+	policy Policy
+}
+
+var _ Service = (*lockoutService)(nil)
+
+// Option is a lockout service option
+type Option func(*lockoutService)
+
+// WithPolicy sets the lockout policy
+func WithPolicy(p Policy) Option {
+	return func(svc *lockoutService) {
+		svc.policy = p
+	}
+}
+
+// NewService returns a new lockout service
+func NewService(repo Repository, opts ...Option) Service {
+	svc := &lockoutService{repo: repo, policy: DefaultPolicy}
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
+}
+
+func (svc *lockoutService) Check(ctx context.Context, client string) (time.Duration, error) {
+	lockout, err := svc.repo.GetLockout(ctx, client)
+	if err != nil {
+		if errors.Is(err, ErrLockoutNotFound) {
+			return 0, nil
+		}
+
+		return 0, errors.Wrap(err, "get lockout")
+	}
+
+	now := time.Now()
+	if lockout.Locked(now) {
+		return lockout.RetryAfter(now), ErrClientLocked
+	}
+
+	return 0, nil
+}
+
+func (svc *lockoutService) RecordFailure(ctx context.Context, client string) (*Lockout, error) {
+	lockout, err := svc.repo.RecordFailure(ctx, client, svc.policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "record failure")
+	}
+
+	return lockout, nil
+}
+
+func (svc *lockoutService) ListLockouts(ctx context.Context) ([]*Lockout, error) {
+	lockouts, err := svc.repo.ListLockouts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list lockouts")
+	}
+
+	return lockouts, nil
+}
+
+func (svc *lockoutService) ClearLockout(ctx context.Context, client string) error {
+	err := svc.repo.DeleteLockout(ctx, client)
+	return errors.Wrap(err, "delete lockout")
+}
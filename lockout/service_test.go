@@ -0,0 +1,70 @@
+package lockout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/lockout"
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestService(t *testing.T) {
+	storagetest.Run(t, testService)
+}
+
+func testService(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	lockoutRepo := repos.Lockout
+	lockoutSvc := lockout.NewService(lockoutRepo, lockout.WithPolicy(lockout.Policy{
+		MaxFailures: 2,
+		Duration:    time.Minute,
+		MaxDuration: time.Hour,
+		ResetAfter:  time.Hour,
+	}))
+
+	ctx := context.TODO()
+	client := "192.0.2.1"
+
+	retryAfter, err := lockoutSvc.Check(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	l, err := lockoutSvc.RecordFailure(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, l.Failures)
+
+	_, err = lockoutSvc.Check(ctx, client)
+	require.NoError(t, err)
+
+	l, err = lockoutSvc.RecordFailure(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, 2, l.Failures)
+
+	retryAfter, err = lockoutSvc.Check(ctx, client)
+	assert.ErrorIs(t, err, lockout.ErrClientLocked)
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, time.Minute)
+
+	t.Run("list lockouts", func(t *testing.T) {
+		lockouts, err := lockoutSvc.ListLockouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, lockouts, 1)
+		assert.Equal(t, client, lockouts[0].Client)
+		assert.Equal(t, 2, lockouts[0].Failures)
+	})
+
+	t.Run("clear lockout", func(t *testing.T) {
+		err := lockoutSvc.ClearLockout(ctx, client)
+		require.NoError(t, err)
+
+		_, err = lockoutSvc.Check(ctx, client)
+		assert.NoError(t, err)
+
+		err = lockoutSvc.ClearLockout(ctx, client)
+		assert.ErrorIs(t, err, lockout.ErrLockoutNotFound)
+	})
+}
@@ -3,7 +3,9 @@ package openapi
 import (
 	"net/http"
 
+	"github.com/stevenferrer/invitesvc/audit"
 	"github.com/stevenferrer/invitesvc/authn"
+	"github.com/stevenferrer/invitesvc/token"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
@@ -45,21 +47,149 @@ func NewOpenAPI3() openapi3.T {
 				WithName(authn.AuthKeyHeader).
 				WithIn("header"),
 		},
+		"request_signature": &openapi3.SecuritySchemeRef{
+			Value: openapi3.NewSecurityScheme().
+				WithDescription("Authenticate with a request signed with the signing key " +
+					"of an auth key, see " + authn.SignatureKeyIDHeader + ", " +
+					authn.SignatureTimestampHeader + " and " + authn.SignatureNonceHeader).
+				WithType("apiKey").
+				WithName(authn.SignatureHeader).
+				WithIn("header"),
+		},
+		"bearer_jwt": &openapi3.SecuritySchemeRef{
+			Value: openapi3.NewJWTSecurityScheme().
+				WithDescription("Authenticate with JWT bearer token, " +
+					"only when the server is configured with a JWKS"),
+		},
 	}
 
+	// admin operations accept either an auth key, a signed request or a JWT bearer token
+	adminSecurity := openapi3.NewSecurityRequirements().
+		With(openapi3.NewSecurityRequirement().Authenticate("auth_key")).
+		With(openapi3.NewSecurityRequirement().Authenticate("request_signature")).
+		With(openapi3.NewSecurityRequirement().Authenticate("bearer_jwt"))
+
 	spec.Components.Schemas = openapi3.Schemas{
 		"TokenString": openapi3.NewSchemaRef("", openapi3.NewStringSchema().
 			WithLength(12).WithDefault("VxzUfkY36YQT")),
+		"TokenStatus": openapi3.NewSchemaRef("", openapi3.NewStringSchema().
+			WithEnum("active", "redeemed", "disabled", "expired", "revoked", "pending")),
 		"AuthKey": openapi3.NewSchemaRef("", openapi3.NewStringSchema().
 			WithLength(32).WithDefault("0d8ee59c4c1f4571a61a887b28ef7612")),
+		"AuthKeyScope": openapi3.NewSchemaRef("", openapi3.NewStringSchema().
+			WithEnum("tokens:read", "tokens:write", "keys:admin", "audit:read")),
+		"AuditEvent": openapi3.NewSchemaRef("",
+			openapi3.NewObjectSchema().
+				WithProperty("id", openapi3.NewStringSchema()).
+				WithProperty("actor", openapi3.NewStringSchema()).
+				WithProperty("authMethod", openapi3.NewStringSchema().
+					WithEnum("authkey", "signature", "jwt", "clientcert")).
+				WithProperty("action", openapi3.NewStringSchema()).
+				WithProperty("target", openapi3.NewStringSchema()).
+				WithProperty("status", openapi3.NewIntegerSchema()).
+				WithProperty("requestId", openapi3.NewStringSchema()).
+				WithProperty("ipAddress", openapi3.NewStringSchema()).
+				WithProperty("createdAt", openapi3.NewDateTimeSchema())),
+		"AuthKeyInfo": openapi3.NewSchemaRef("",
+			openapi3.NewObjectSchema().
+				WithProperty("keyId", openapi3.NewStringSchema().
+					WithLength(8)).
+				WithProperty("name", openapi3.NewStringSchema()).
+				WithProperty("owner", openapi3.NewStringSchema()).
+				WithPropertyRef("scopes", &openapi3.SchemaRef{
+					Value: &openapi3.Schema{
+						Type: "array",
+						Items: &openapi3.SchemaRef{
+							Ref: "#/components/schemas/AuthKeyScope",
+						},
+					},
+				}).
+				WithProperty("active", openapi3.NewBoolSchema()).
+				WithProperty("signing", openapi3.NewBoolSchema()).
+				WithProperty("expiresAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("revokedAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("lastUsedAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("createdAt", openapi3.NewDateTimeSchema())),
 		"Token": openapi3.NewSchemaRef("",
 			openapi3.NewObjectSchema().
 				WithPropertyRef("token", &openapi3.SchemaRef{
 					Ref: "#/components/schemas/TokenString",
 				}).
+				WithPropertyRef("status", &openapi3.SchemaRef{
+					Ref: "#/components/schemas/TokenStatus",
+				}).
 				WithProperty("redeemed", openapi3.NewBoolSchema()).
-				WithProperty("expiration", openapi3.NewDateTimeSchema()).
-				WithProperty("disabled", openapi3.NewBoolSchema())),
+				WithProperty("redeemedAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("expiration", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("notBefore", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("disabled", openapi3.NewBoolSchema()).
+				WithProperty("revoked", openapi3.NewBoolSchema()).
+				WithProperty("revokeReason", openapi3.NewStringSchema()).
+				WithProperty("maxRedemptions", openapi3.NewIntegerSchema()).
+				WithProperty("redemptions", openapi3.NewIntegerSchema()).
+				WithProperty("remaining", openapi3.NewIntegerSchema()).
+				WithProperty("metadata", openapi3.NewObjectSchema()).
+				WithProperty("labels", openapi3.NewArraySchema().
+					WithItems(openapi3.NewStringSchema())).
+				WithProperty("campaign", openapi3.NewStringSchema()).
+				WithProperty("createdAt", openapi3.NewDateTimeSchema())),
+		"Campaign": openapi3.NewSchemaRef("",
+			openapi3.NewObjectSchema().
+				WithProperty("id", openapi3.NewStringSchema()).
+				WithProperty("name", openapi3.NewStringSchema()).
+				WithProperty("ttl", openapi3.NewStringSchema()).
+				WithProperty("maxRedemptions", openapi3.NewIntegerSchema()).
+				WithProperty("startsAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("endsAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("maxTokens", openapi3.NewIntegerSchema()).
+				WithProperty("tokens", openapi3.NewIntegerSchema()).
+				WithProperty("disabled", openapi3.NewBoolSchema()).
+				WithProperty("active", openapi3.NewBoolSchema()).
+				WithProperty("createdAt", openapi3.NewDateTimeSchema()).
+				WithProperty("updatedAt", openapi3.NewDateTimeSchema())),
+		"Lockout": openapi3.NewSchemaRef("",
+			openapi3.NewObjectSchema().
+				WithProperty("client", openapi3.NewStringSchema()).
+				WithProperty("failures", openapi3.NewIntegerSchema()).
+				WithProperty("lockedUntil", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("failedAt", openapi3.NewDateTimeSchema().
+					WithNullable()).
+				WithProperty("locked", openapi3.NewBoolSchema())),
+		"Redemption": openapi3.NewSchemaRef("",
+			openapi3.NewObjectSchema().
+				WithProperty("id", openapi3.NewStringSchema()).
+				WithProperty("userId", openapi3.NewStringSchema()).
+				WithProperty("email", openapi3.NewStringSchema()).
+				WithProperty("metadata", openapi3.NewObjectSchema().
+					WithAdditionalProperties(openapi3.NewStringSchema())).
+				WithProperty("ipAddress", openapi3.NewStringSchema()).
+				WithProperty("userAgent", openapi3.NewStringSchema()).
+				WithProperty("createdAt", openapi3.NewDateTimeSchema())),
+		"TokenDetails": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				AllOf: openapi3.SchemaRefs{
+					{Ref: "#/components/schemas/Token"},
+					openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+						WithPropertyRef("redemptions", &openapi3.SchemaRef{
+							Value: &openapi3.Schema{
+								Type: "array",
+								Items: &openapi3.SchemaRef{
+									Ref: "#/components/schemas/Redemption",
+								},
+							},
+						})),
+				},
+			},
+		},
 		"Tokens": &openapi3.SchemaRef{
 			Value: &openapi3.Schema{
 				Type: "array",
@@ -70,7 +200,108 @@ func NewOpenAPI3() openapi3.T {
 		},
 	}
 
+	spec.Components.RequestBodies = openapi3.RequestBodies{
+		"GenerateAuthKeyRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Generate auth key request, the auth key never expires when ttl and expiresAt are not set").
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("name", openapi3.NewStringSchema()).
+					WithProperty("owner", openapi3.NewStringSchema()).
+					WithPropertyRef("scopes", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/AuthKeyScope",
+							},
+						},
+					}).
+					WithProperty("ttl", openapi3.NewStringSchema()).
+					WithProperty("expiresAt", openapi3.NewDateTimeSchema()).
+					WithProperty("signing", openapi3.NewBoolSchema())),
+		},
+
+		"GenerateTokenRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Generate token request").
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("count", openapi3.NewIntegerSchema().
+						WithMin(1).WithMax(token.MaxBatchSize)).
+					WithProperty("maxRedemptions", openapi3.NewIntegerSchema().
+						WithMin(1).WithDefault(1)).
+					WithProperty("ttl", openapi3.NewStringSchema().
+						WithDefault("168h")).
+					WithProperty("expiresAt", openapi3.NewDateTimeSchema()).
+					WithProperty("neverExpires", openapi3.NewBoolSchema().
+						WithDefault(false)).
+					WithProperty("notBefore", openapi3.NewDateTimeSchema()).
+					WithProperty("metadata", openapi3.NewObjectSchema()).
+					WithProperty("labels", openapi3.NewArraySchema().
+						WithItems(openapi3.NewStringSchema().
+							WithMinLength(1).WithMaxLength(64)).
+						WithMaxItems(token.MaxLabels)).
+					WithProperty("campaign", openapi3.NewStringSchema())),
+		},
+
+		"CampaignRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Create or update campaign request, zero values use the service defaults").
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("name", openapi3.NewStringSchema().
+						WithMinLength(1).WithMaxLength(128)).
+					WithProperty("ttl", openapi3.NewStringSchema()).
+					WithProperty("maxRedemptions", openapi3.NewIntegerSchema().
+						WithMin(0)).
+					WithProperty("startsAt", openapi3.NewDateTimeSchema()).
+					WithProperty("endsAt", openapi3.NewDateTimeSchema()).
+					WithProperty("maxTokens", openapi3.NewIntegerSchema().
+						WithMin(0))),
+		},
+
+		"ExtendTokenRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Extend token request, exactly one of the properties must be set").
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("ttl", openapi3.NewStringSchema()).
+					WithProperty("expiresAt", openapi3.NewDateTimeSchema()).
+					WithProperty("neverExpires", openapi3.NewBoolSchema())),
+		},
+
+		"RevokeTokenRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Revoke token request").
+				WithRequired(true).
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("reason", openapi3.NewStringSchema())),
+		},
+
+		"RedeemTokenRequest": &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Redeem token request").
+				WithJSONSchema(openapi3.NewSchema().
+					WithProperty("userId", openapi3.NewStringSchema().
+						WithMaxLength(token.MaxUserIDLen)).
+					WithProperty("email", openapi3.NewStringSchema().
+						WithMaxLength(token.MaxEmailLen)).
+					WithProperty("metadata", openapi3.NewObjectSchema().
+						WithAdditionalProperties(openapi3.NewStringSchema()))),
+		},
+	}
+
 	spec.Components.Responses = openapi3.Responses{
+		"Error400Response": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Bad request error").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema()))),
+		},
+
+		"Error413Response": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Request body too large").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema()))),
+		},
+
 		"Error500Response": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Internal server error").
@@ -78,6 +309,13 @@ func NewOpenAPI3() openapi3.T {
 					WithProperty("message", openapi3.NewStringSchema()))),
 		},
 
+		"Error403Response": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Forbidden error, the auth key is missing the required scope").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema()))),
+		},
+
 		"Error404Response": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Not found error").
@@ -85,6 +323,13 @@ func NewOpenAPI3() openapi3.T {
 					WithProperty("message", openapi3.NewStringSchema()))),
 		},
 
+		"Error409Response": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Conflict error").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema()))),
+		},
+
 		"Error422Response": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Unprocessable entity error").
@@ -93,46 +338,122 @@ func NewOpenAPI3() openapi3.T {
 		},
 
 		"Error429Response": &openapi3.ResponseRef{
-			Value: openapi3.NewResponse().
-				WithDescription("Too many request error").
+			Value: withRetryAfter(openapi3.NewResponse().
+				WithDescription("Too many request error, retry-after is set when the client is locked out").
 				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
-					WithProperty("message", openapi3.NewStringSchema()))),
+					WithProperty("message", openapi3.NewStringSchema())))),
 		},
 
 		"GenerateTokenResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
-				WithDescription("Generate token response").
+				WithDescription("Generate token response, contains tokens instead of token when count is set").
 				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
 					WithPropertyRef("token", &openapi3.SchemaRef{
 						Ref: "#/components/schemas/TokenString",
+					}).
+					WithPropertyRef("tokens", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/TokenString",
+							},
+						},
 					}))),
 		},
 
 		"ListTokensResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("List tokens response").
-				WithContent(openapi3.NewContentWithJSONSchemaRef(&openapi3.SchemaRef{
-					Ref: "#/components/schemas/Tokens",
-				})),
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("tokens", &openapi3.SchemaRef{
+						Ref: "#/components/schemas/Tokens",
+					}).
+					WithProperty("nextCursor", openapi3.NewStringSchema()))),
+		},
+
+		"TokenSummaryResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Token summary response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("total", openapi3.NewIntegerSchema()).
+					WithProperty("active", openapi3.NewIntegerSchema()).
+					WithProperty("redeemed", openapi3.NewIntegerSchema()).
+					WithProperty("disabled", openapi3.NewIntegerSchema()).
+					WithProperty("expired", openapi3.NewIntegerSchema()).
+					WithProperty("revoked", openapi3.NewIntegerSchema()).
+					WithProperty("pending", openapi3.NewIntegerSchema()))),
 		},
 
 		"GetTokenResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Get token response").
 				WithContent(openapi3.NewContentWithJSONSchemaRef(&openapi3.SchemaRef{
-					Ref: "#/components/schemas/Token",
+					Ref: "#/components/schemas/TokenDetails",
 				})),
 		},
 
 		"GenerateAuthKeyResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
-				WithDescription("Generate auth key response").
+				WithDescription("Generate auth key response, the auth key is only shown once").
 				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
 					WithPropertyRef("authKey", &openapi3.SchemaRef{
 						Ref: "#/components/schemas/AuthKey",
+					}).
+					WithProperty("keyId", openapi3.NewStringSchema().
+						WithLength(8).WithDefault("0d8ee59c")))),
+		},
+
+		"ListAuthKeysResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("List auth keys response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("authKeys", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/AuthKeyInfo",
+							},
+						},
 					}))),
 		},
 
+		"ListAuditEventsResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("List audit events response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("events", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/AuditEvent",
+							},
+						},
+					}).
+					WithProperty("nextCursor", openapi3.NewStringSchema()))),
+		},
+
+		"RevokeAuthKeyResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Revoke auth key response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("auth key successfully revoked.")))),
+		},
+
+		"ValidateTokenResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Validate token response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("token", &openapi3.SchemaRef{
+						Ref: "#/components/schemas/TokenString",
+					}).
+					WithProperty("valid", openapi3.NewBoolSchema()).
+					WithProperty("reason", openapi3.NewStringSchema().
+						WithEnum("valid", "not_found", "revoked", "disabled",
+							"expired", "not_yet_valid", "redeemed", "exhausted")).
+					WithProperty("message", openapi3.NewStringSchema()))),
+		},
+
 		"RedeemTokenResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Redeem token response").
@@ -148,6 +469,98 @@ func NewOpenAPI3() openapi3.T {
 					WithProperty("message", openapi3.NewStringSchema().
 						WithDefault("token successfully disabled.")))),
 		},
+
+		"EnableTokenResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Enable token response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("token successfully enabled.")))),
+		},
+
+		"ExtendTokenResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Extend token response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("token successfully extended.")))),
+		},
+
+		"RevokeTokenResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Revoke token response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("token successfully revoked.")))),
+		},
+
+		"CampaignResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Campaign response").
+				WithContent(openapi3.NewContentWithJSONSchemaRef(&openapi3.SchemaRef{
+					Ref: "#/components/schemas/Campaign",
+				})),
+		},
+
+		"ListCampaignsResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("List campaigns response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("campaigns", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/Campaign",
+							},
+						},
+					}))),
+		},
+
+		"DisableCampaignResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Disable campaign response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("campaign successfully disabled.")))),
+		},
+
+		"EnableCampaignResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Enable campaign response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("campaign successfully enabled.")))),
+		},
+
+		"DeleteCampaignResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Delete campaign response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("campaign successfully deleted.")))),
+		},
+
+		"ListLockoutsResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("List lockouts response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithPropertyRef("lockouts", &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: "array",
+							Items: &openapi3.SchemaRef{
+								Ref: "#/components/schemas/Lockout",
+							},
+						},
+					}))),
+		},
+
+		"ClearLockoutResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Clear lockout response").
+				WithContent(openapi3.NewContentWithJSONSchema(openapi3.NewSchema().
+					WithProperty("message", openapi3.NewStringSchema().
+						WithDefault("lockout successfully cleared.")))),
+		},
 	}
 
 	spec.Paths = openapi3.Paths{
@@ -155,19 +568,117 @@ func NewOpenAPI3() openapi3.T {
 			Post: &openapi3.Operation{
 				OperationID: "GenerateAuthKey",
 				Summary:     "Generate auth key",
-				Description: "Generate auth key for accessing invite service API.",
+				Description: "Generate auth key for accessing invite service API. Only the hash of the auth key is stored, so the auth key is only shown once.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/GenerateAuthKeyRequest",
+				},
 				Responses: openapi3.Responses{
 					"201": &openapi3.ResponseRef{
 						Ref: "#/components/responses/GenerateAuthKeyResponse",
 					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error500Response",
 					},
 				},
-				Security: openapi3.NewSecurityRequirements().
-					With(openapi3.NewSecurityRequirement().
-						Authenticate("auth_key")),
-				Tags: []string{"Admin"},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/authkeys": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "ListAuthKeys",
+				Summary:     "List auth keys",
+				Description: "List auth keys, the auth keys themselves are never included.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ListAuthKeysResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/audit": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "ListAuditEvents",
+				Summary:     "List audit events",
+				Description: "Retrieve a page of audit events of admin actions, newest first.",
+				Parameters: openapi3.Parameters{
+					{Value: openapi3.NewQueryParameter("actor").
+						WithDescription("Filter by actor, e.g. the auth key id").
+						WithSchema(openapi3.NewStringSchema())},
+					{Value: openapi3.NewQueryParameter("action").
+						WithDescription("Filter by action, e.g. token.disable").
+						WithSchema(openapi3.NewStringSchema())},
+					{Value: openapi3.NewQueryParameter("target").
+						WithDescription("Filter by target, e.g. the token").
+						WithSchema(openapi3.NewStringSchema())},
+					{Value: openapi3.NewQueryParameter("limit").
+						WithDescription("Max number of events per page").
+						WithSchema(openapi3.NewIntegerSchema().
+							WithMin(1).WithMax(audit.MaxListLimit).
+							WithDefault(audit.DefaultListLimit))},
+					{Value: openapi3.NewQueryParameter("cursor").
+						WithDescription("Cursor of the next page").
+						WithSchema(openapi3.NewStringSchema())},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ListAuditEventsResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/authkeys/{keyId}/revoke": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "RevokeAuthKey",
+				Summary:     "Revoke auth key",
+				Description: "Revoke an auth key, revoked auth keys can't be used anymore.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/RevokeAuthKeyResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
 			},
 		},
 
@@ -176,36 +687,102 @@ func NewOpenAPI3() openapi3.T {
 				OperationID: "GenerateToken",
 				Summary:     "Generate invite token",
 				Description: "Generate invite tokens and share to your customers.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/GenerateTokenRequest",
+				},
 				Responses: openapi3.Responses{
 					"201": &openapi3.ResponseRef{
 						Ref: "#/components/responses/GenerateTokenResponse",
 					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error500Response",
 					},
 				},
-				Security: openapi3.NewSecurityRequirements().
-					With(openapi3.NewSecurityRequirement().
-						Authenticate("auth_key")),
-				Tags: []string{"Admin"},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
 			},
 
 			Get: &openapi3.Operation{
 				OperationID: "ListTokens",
 				Summary:     "List invite tokens",
-				Description: "Retrieve list of invite tokens.",
+				Description: "Retrieve a page of invite tokens.",
+				Parameters: openapi3.Parameters{
+					{Value: openapi3.NewQueryParameter("status").
+						WithDescription("Filter by token status").
+						WithSchema(openapi3.NewStringSchema().
+							WithEnum("active", "redeemed", "disabled", "expired", "revoked", "pending"))},
+					{Value: openapi3.NewQueryParameter("createdAfter").
+						WithDescription("Filter tokens created at or after the timestamp").
+						WithSchema(openapi3.NewDateTimeSchema())},
+					{Value: openapi3.NewQueryParameter("createdBefore").
+						WithDescription("Filter tokens created before the timestamp").
+						WithSchema(openapi3.NewDateTimeSchema())},
+					{Value: openapi3.NewQueryParameter("label").
+						WithDescription("Filter tokens having the label, can be repeated to require all labels").
+						WithSchema(openapi3.NewArraySchema().
+							WithItems(openapi3.NewStringSchema()))},
+					{Value: openapi3.NewQueryParameter("campaign").
+						WithDescription("Filter tokens generated under the campaign").
+						WithSchema(openapi3.NewStringSchema())},
+					{Value: openapi3.NewQueryParameter("sort").
+						WithDescription("Sort order by creation timestamp").
+						WithSchema(openapi3.NewStringSchema().
+							WithEnum("asc", "desc").WithDefault("asc"))},
+					{Value: openapi3.NewQueryParameter("limit").
+						WithDescription("Max number of tokens per page").
+						WithSchema(openapi3.NewIntegerSchema().
+							WithMin(1).WithMax(token.MaxListLimit).
+							WithDefault(token.DefaultListLimit))},
+					{Value: openapi3.NewQueryParameter("cursor").
+						WithDescription("Cursor of the next page").
+						WithSchema(openapi3.NewStringSchema())},
+				},
 				Responses: openapi3.Responses{
 					"200": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ListTokensResponse",
 					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/tokens/summary": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "SummarizeTokens",
+				Summary:     "Summarize invite tokens",
+				Description: "Retrieve the number of invite tokens per status.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/TokenSummaryResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error500Response",
 					},
 				},
-				Security: openapi3.NewSecurityRequirements().
-					With(openapi3.NewSecurityRequirement().
-						Authenticate("auth_key")),
-				Tags: []string{"Admin"},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
 			},
 		},
 
@@ -221,14 +798,15 @@ func NewOpenAPI3() openapi3.T {
 					"404": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error404Response",
 					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error500Response",
 					},
 				},
-				Security: openapi3.NewSecurityRequirements().
-					With(openapi3.NewSecurityRequirement().
-						Authenticate("auth_key")),
-				Tags: []string{"Admin"},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
 			},
 		},
 
@@ -244,14 +822,321 @@ func NewOpenAPI3() openapi3.T {
 					"404": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error404Response",
 					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error500Response",
 					},
 				},
-				Security: openapi3.NewSecurityRequirements().
-					With(openapi3.NewSecurityRequirement().
-						Authenticate("auth_key")),
-				Tags: []string{"Admin"},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/tokens/{token}/enable": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "EnableToken",
+				Summary:     "Enable invite token",
+				Description: "Re-enable a disabled invite token. Revoked and fully redeemed tokens cannot be re-enabled.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/EnableTokenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/tokens/{token}/extend": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "ExtendToken",
+				Summary:     "Extend invite token",
+				Description: "Extend the expiration of an invite token. The new expiration must be later than the current expiration.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/ExtendTokenRequest",
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ExtendTokenResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/tokens/{token}/revoke": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "RevokeToken",
+				Summary:     "Revoke invite token",
+				Description: "Permanently revoke an invite token with a reason.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/RevokeTokenRequest",
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/RevokeTokenResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/campaigns": &openapi3.PathItem{
+			Post: &openapi3.Operation{
+				OperationID: "CreateCampaign",
+				Summary:     "Create invite campaign",
+				Description: "Create an invite campaign. Tokens generated under the campaign inherit its settings.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/CampaignRequest",
+				},
+				Responses: openapi3.Responses{
+					"201": &openapi3.ResponseRef{
+						Ref: "#/components/responses/CampaignResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+			Get: &openapi3.Operation{
+				OperationID: "ListCampaigns",
+				Summary:     "List invite campaigns",
+				Description: "Retrieve all invite campaigns.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ListCampaignsResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/campaigns/{campaign}": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "GetCampaign",
+				Summary:     "Retrieve invite campaign",
+				Description: "Retrieve invite campaign details.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/CampaignResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+			Put: &openapi3.Operation{
+				OperationID: "UpdateCampaign",
+				Summary:     "Update invite campaign",
+				Description: "Update the settings of an invite campaign. The settings only apply to tokens generated after the update.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/CampaignRequest",
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/CampaignResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+			Delete: &openapi3.Operation{
+				OperationID: "DeleteCampaign",
+				Summary:     "Delete invite campaign",
+				Description: "Delete an invite campaign. Campaigns with tokens cannot be deleted, disable them instead.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/DeleteCampaignResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error409Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/campaigns/{campaign}/disable": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "DisableCampaign",
+				Summary:     "Disable invite campaign",
+				Description: "Disable an invite campaign. Tokens of a disabled campaign cannot be redeemed.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/DisableCampaignResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/campaigns/{campaign}/enable": &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				OperationID: "EnableCampaign",
+				Summary:     "Enable invite campaign",
+				Description: "Re-enable a disabled invite campaign.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/EnableCampaignResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/lockouts": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "ListLockouts",
+				Summary:     "List lockouts",
+				Description: "List the clients with unknown token attempts on the public routes, last failed first.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ListLockoutsResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
+			},
+		},
+
+		"/admin/lockouts/{client}": &openapi3.PathItem{
+			Delete: &openapi3.Operation{
+				OperationID: "ClearLockout",
+				Summary:     "Clear lockout",
+				Description: "Forget the unknown token attempts of a client and lift its lockout.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ClearLockoutResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error404Response",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error403Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Security: adminSecurity,
+				Tags:     []string{"Admin"},
 			},
 		},
 
@@ -260,13 +1145,22 @@ func NewOpenAPI3() openapi3.T {
 				OperationID: "RedeemToken",
 				Summary:     "Redeem invite token",
 				Description: "Redeem an invite token.",
+				RequestBody: &openapi3.RequestBodyRef{
+					Ref: "#/components/requestBodies/RedeemTokenRequest",
+				},
 				Responses: openapi3.Responses{
 					"200": &openapi3.ResponseRef{
 						Ref: "#/components/responses/RedeemTokenResponse",
 					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error400Response",
+					},
 					"404": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error404Response",
 					},
+					"413": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error413Response",
+					},
 					"422": &openapi3.ResponseRef{
 						Ref: "#/components/responses/Error422Response",
 					},
@@ -280,6 +1174,38 @@ func NewOpenAPI3() openapi3.T {
 				Tags: []string{"Public"},
 			},
 		},
+
+		"/tokens/{token}/validate": &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: "ValidateToken",
+				Summary:     "Validate invite token",
+				Description: "Check if an invite token can be redeemed without redeeming it. " +
+					"The validation result is returned as a machine-readable reason.",
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ValidateTokenResponse",
+					},
+					"429": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error429Response",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/Error500Response",
+					},
+				},
+				Tags: []string{"Public"},
+			},
+		},
+	}
+
+	// admin calls are throttled per client ip and per caller
+	for _, path := range spec.Paths {
+		for _, op := range path.Operations() {
+			if op.Security == adminSecurity {
+				op.Responses["429"] = &openapi3.ResponseRef{
+					Ref: "#/components/responses/Error429Response",
+				}
+			}
+		}
 	}
 
 	return spec
@@ -293,3 +1219,17 @@ func InitOpenAPI3Routes(e *echo.Echo) {
 		return c.JSONPretty(http.StatusOK, spec, " ")
 	})
 }
+
+// withRetryAfter adds the retry-after header to the response
+func withRetryAfter(resp *openapi3.Response) *openapi3.Response {
+	resp.Headers = openapi3.Headers{
+		"Retry-After": &openapi3.HeaderRef{
+			Value: &openapi3.Header{Parameter: openapi3.Parameter{
+				Description: "Number of seconds until the lockout ends",
+				Schema:      openapi3.NewIntegerSchema().NewRef(),
+			}},
+		},
+	}
+
+	return resp
+}
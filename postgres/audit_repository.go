@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/audit"
+)
+
+// AuditRepository is an audit repository that uses postgres as backend
+type AuditRepository struct {
+	db *sql.DB
+}
+
+var _ audit.Repository = (*AuditRepository)(nil)
+
+// NewAuditRepository retuns a new audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// CreateEvent inserts an audit event into the db
+func (repo *AuditRepository) CreateEvent(ctx context.Context, event *audit.Event) error {
+	stmnt := `insert into audit_events (event_id, actor, auth_method, action, 
+		target, status, request_id, ip_address) values ($1, $2, $3, $4, $5, $6, $7, $8) 
+		returning created_at`
+	err := repo.db.QueryRowContext(ctx, stmnt, event.ID, event.Actor,
+		event.AuthMethod, event.Action, event.Target, event.Status,
+		event.RequestID, event.IPAddress).Scan(&event.CreatedAt)
+	return errors.Wrap(err, "insert event")
+}
+
+// auditCols are the audit event columns in the order expected by scanEvent
+const auditCols = `event_id, actor, auth_method, action, target, 
+	status, request_id, ip_address, created_at`
+
+// scanEvent scans an audit event from a row selected with auditCols
+func scanEvent(row scanner) (*audit.Event, error) {
+	var event audit.Event
+	err := row.Scan(&event.ID, &event.Actor, &event.AuthMethod,
+		&event.Action, &event.Target, &event.Status, &event.RequestID,
+		&event.IPAddress, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// ListEvents retrieves the audit events matching the query from the db
+func (repo *AuditRepository) ListEvents(ctx context.Context, query audit.ListQuery) ([]*audit.Event, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	// arg adds an argument and returns its placeholder
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.Actor != "" {
+		conds = append(conds, "actor = "+arg(query.Actor))
+	}
+
+	if query.Action != "" {
+		conds = append(conds, "action = "+arg(query.Action))
+	}
+
+	if query.Target != "" {
+		conds = append(conds, "target = "+arg(query.Target))
+	}
+
+	if query.After != nil {
+		conds = append(conds, fmt.Sprintf("(created_at, event_id) < (%s, %s)",
+			arg(query.After.CreatedAt.UTC()), arg(query.After.ID)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`select ` + auditCols + ` from audit_events`)
+	if len(conds) > 0 {
+		sb.WriteString(" where " + strings.Join(conds, " and "))
+	}
+	sb.WriteString(" order by created_at desc, event_id desc")
+	if query.Limit > 0 {
+		sb.WriteString(" limit " + arg(query.Limit))
+	}
+
+	rows, err := repo.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query events")
+	}
+	defer rows.Close()
+
+	events := make([]*audit.Event, 0, 10)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return events, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"github.com/stevenferrer/invitesvc/authn"
@@ -21,21 +22,187 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
-// CreateAuthKey inserts an auth key into the db
-func (repo *AuthRepository) CreateAuthKey(ctx context.Context, authKey authn.AuthKey) error {
-	stmnt := `insert into auth_keys (auth_key) values ($1)`
-	_, err := repo.db.ExecContext(ctx, stmnt, authKey)
-	return errors.Wrap(err, "insert auth key")
+// CreateAuthKey inserts an auth record into the db
+func (repo *AuthRepository) CreateAuthKey(ctx context.Context, auth *authn.Auth) error {
+	stmnt, args := insertAuthKeyStmnt(auth)
+	_, err := repo.db.ExecContext(ctx, stmnt, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return authn.ErrDuplicateAuthKey
+		}
+
+		return errors.Wrap(err, "insert auth key")
+	}
+
+	return nil
 }
 
-// AuthKeyExists checks db if auth key exists
-func (repo *AuthRepository) AuthKeyExists(ctx context.Context, authKey authn.AuthKey) (bool, error) {
-	stmnt := `select exists(select 1 from auth_keys where auth_key=$1)`
+// CreateInitialAuthKey inserts an auth record into the db only
+// when there are no auth records yet
+func (repo *AuthRepository) CreateInitialAuthKey(ctx context.Context, auth *authn.Auth) (err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// block concurrent inserts, e.g. from other replicas starting up
+	_, err = tx.ExecContext(ctx, `lock table auth_keys in share row exclusive mode`)
+	if err != nil {
+		return errors.Wrap(err, "lock auth keys")
+	}
+
 	var exists bool
-	err := repo.db.QueryRowContext(ctx, stmnt, authKey).Scan(&exists)
-	if err != nil && err != sql.ErrNoRows {
-		return false, errors.Wrap(err, "query auth key exists")
+	stmnt := `select exists(select 1 from auth_keys)`
+	err = tx.QueryRowContext(ctx, stmnt).Scan(&exists)
+	if err != nil {
+		return errors.Wrap(err, "query auth keys exist")
+	}
+
+	if exists {
+		return authn.ErrAuthKeysExist
+	}
+
+	stmnt, args := insertAuthKeyStmnt(auth)
+	_, err = tx.ExecContext(ctx, stmnt, args...)
+	if err != nil {
+		return errors.Wrap(err, "insert auth key")
+	}
+
+	err = tx.Commit()
+	return errors.Wrap(err, "commit tx")
+}
+
+// insertAuthKeyStmnt returns the insert statement and args of an auth record
+func insertAuthKeyStmnt(auth *authn.Auth) (string, []interface{}) {
+	scopes := make(pq.StringArray, 0, len(auth.Scopes))
+	for _, scope := range auth.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	stmnt := `insert into auth_keys (key_id, key_hash, salt, name, owner, 
+		scopes, signing_key, expires_at) values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	return stmnt, []interface{}{auth.ID, auth.Hash, auth.Salt,
+		auth.Name, auth.Owner, scopes, auth.SigningKey, auth.ExpiresAt}
+}
+
+// authCols are the auth key columns in the order expected by scanAuth
+const authCols = `key_id, key_hash, salt, name, owner, scopes, 
+	signing_key, expires_at, revoked_at, last_used_at, created_at`
+
+// scanAuth scans an auth record from a row selected with authCols
+func scanAuth(row scanner) (*authn.Auth, error) {
+	var (
+		auth   authn.Auth
+		scopes pq.StringArray
+	)
+	err := row.Scan(&auth.ID, &auth.Hash, &auth.Salt, &auth.Name,
+		&auth.Owner, &scopes, &auth.SigningKey, &auth.ExpiresAt, &auth.RevokedAt,
+		&auth.LastUsedAt, &auth.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Scopes = make([]authn.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		auth.Scopes = append(auth.Scopes, authn.Scope(scope))
+	}
+
+	return &auth, nil
+}
+
+// GetAuthKey retrieves an auth record from the db
+func (repo *AuthRepository) GetAuthKey(ctx context.Context, id authn.KeyID) (*authn.Auth, error) {
+	stmnt := `select ` + authCols + ` from auth_keys where key_id=$1`
+	auth, err := scanAuth(repo.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, authn.ErrAuthKeyNotFound
+		}
+
+		return nil, errors.Wrap(err, "query auth key")
+	}
+
+	return auth, nil
+}
+
+// ListAuthKeys retrieves all auth records from the db
+func (repo *AuthRepository) ListAuthKeys(ctx context.Context) ([]*authn.Auth, error) {
+	stmnt := `select ` + authCols + ` from auth_keys order by created_at, key_id`
+	rows, err := repo.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query auth keys")
+	}
+	defer rows.Close()
+
+	auths := make([]*authn.Auth, 0, 10)
+	for rows.Next() {
+		auth, err := scanAuth(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+
+		auths = append(auths, auth)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return auths, nil
+}
+
+// SetAuthKeyRevoked sets an auth key to revoked
+func (repo *AuthRepository) SetAuthKeyRevoked(ctx context.Context, id authn.KeyID) error {
+	return repo.updateAuthKey(ctx, id, `revoked_at=now()`)
+}
+
+// SetAuthKeyUsed sets the last used timestamp of an auth key to now
+func (repo *AuthRepository) SetAuthKeyUsed(ctx context.Context, id authn.KeyID) error {
+	return repo.updateAuthKey(ctx, id, `last_used_at=now()`)
+}
+
+// CreateNonce inserts a request nonce into the db, expired nonces are removed
+func (repo *AuthRepository) CreateNonce(ctx context.Context, nonce *authn.Nonce) error {
+	stmnt := `delete from auth_nonces where expires_at < now()`
+	_, err := repo.db.ExecContext(ctx, stmnt)
+	if err != nil {
+		return errors.Wrap(err, "delete expired nonces")
+	}
+
+	stmnt = `insert into auth_nonces (key_id, nonce, expires_at) values ($1, $2, $3)`
+	_, err = repo.db.ExecContext(ctx, stmnt, nonce.KeyID, nonce.Value, nonce.ExpiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return authn.ErrNonceReused
+		}
+
+		return errors.Wrap(err, "insert nonce")
+	}
+
+	return nil
+}
+
+// updateAuthKey updates an auth key with the set clause
+func (repo *AuthRepository) updateAuthKey(ctx context.Context, id authn.KeyID, set string) error {
+	stmnt := `update auth_keys set ` + set + ` where key_id=$1`
+	res, err := repo.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return errors.Wrap(err, "update auth key")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return authn.ErrAuthKeyNotFound
 	}
 
-	return exists, nil
+	return nil
 }
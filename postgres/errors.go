@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// uniqueViolation is the postgres unique violation error code
+const uniqueViolation = "23505"
+
+// isUniqueViolation returns true if err is a unique violation error
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import "encoding/json"
+
+// marshalJSON marshals v into a json value, nil values are marshalled
+// into nil so that they are stored as NULL
+func marshalJSON(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(b) == "null" {
+		return nil, nil
+	}
+
+	return b, nil
+}
+
+// unmarshalJSON unmarshals a json value into v, NULL values are ignored
+func unmarshalJSON(b []byte, v interface{}) error {
+	if b == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,11 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestLockoutRepository(t *testing.T) {
+	storagetest.LockoutRepository(t, storagetest.Postgres)
+}
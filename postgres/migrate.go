@@ -2,10 +2,15 @@ package postgres
 
 import (
 	"database/sql"
+	"sort"
+	"strings"
 
-	// postgres driver
-	_ "github.com/lib/pq"
+	// also registers the postgres driver
+	"github.com/lib/pq"
 	"github.com/lopezator/migrator"
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/authn"
 )
 
 // Migrate migrates the database.
@@ -60,5 +65,402 @@ var migrations = migrator.Migrations(
 			return nil
 		},
 	},
+	&migrator.Migration{
+		Name: "Add redemption limit to tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "tokens" 
+				ADD COLUMN max_redemptions integer NOT NULL DEFAULT 1 
+					CHECK (max_redemptions > 0),
+				ADD COLUMN redemptions integer NOT NULL DEFAULT 0`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// previously redeemed tokens were single-use
+			stmnt = `UPDATE "tokens" SET redemptions = 1 
+				WHERE redeemed_at IS NOT NULL`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add expires_at to tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "tokens" ADD COLUMN expires_at timestamptz`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// existing tokens expire 7 days after creation
+			stmnt = `UPDATE "tokens" SET expires_at = created_at + interval '7 days'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create redemptions table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "redemptions" (
+				id uuid PRIMARY KEY,
+				token varchar(12) NOT NULL REFERENCES tokens (token),
+				user_id text NOT NULL DEFAULT '',
+				email text NOT NULL DEFAULT '',
+				metadata jsonb,
+				ip_address text NOT NULL DEFAULT '',
+				user_agent text NOT NULL DEFAULT '',
+				created_at timestamptz NOT NULL DEFAULT NOW()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS redemptions_token_idx 
+				ON "redemptions" (token)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add revocation to tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "tokens" 
+				ADD COLUMN revoked_at timestamptz,
+				ADD COLUMN revoke_reason text NOT NULL DEFAULT ''`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Hash auth keys",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "auth_keys" 
+				ADD COLUMN key_id varchar(8),
+				ADD COLUMN key_hash text,
+				ADD COLUMN salt text`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// read all plaintext keys before updating, a
+			// connection can't be used while rows are open
+			var authKeys []authn.AuthKey
+			rows, err := tx.Query(`SELECT auth_key FROM "auth_keys"`)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var authKey authn.AuthKey
+				if err := rows.Scan(&authKey); err != nil {
+					rows.Close()
+					return err
+				}
+				authKeys = append(authKeys, authKey)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			// the key id is the prefix of the auth key, keys sharing
+			// a prefix can't be told apart once they're hashed
+			if err := checkKeyIDs(authKeys); err != nil {
+				return err
+			}
+
+			for _, authKey := range authKeys {
+				auth, err := authn.NewAuth(authKey)
+				if err != nil {
+					return err
+				}
+
+				stmnt = `UPDATE "auth_keys" SET key_id = $1, 
+					key_hash = $2, salt = $3 WHERE auth_key = $4`
+				_, err = tx.Exec(stmnt, auth.ID, auth.Hash, auth.Salt, authKey)
+				if err != nil {
+					return err
+				}
+			}
+
+			// drop the plaintext keys
+			stmnt = `ALTER TABLE "auth_keys" 
+				DROP COLUMN auth_key,
+				ALTER COLUMN key_id SET NOT NULL,
+				ALTER COLUMN key_hash SET NOT NULL,
+				ALTER COLUMN salt SET NOT NULL,
+				ADD PRIMARY KEY (key_id)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add key management to auth_keys table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "auth_keys" 
+				ADD COLUMN name text NOT NULL DEFAULT '',
+				ADD COLUMN owner text NOT NULL DEFAULT '',
+				ADD COLUMN expires_at timestamptz,
+				ADD COLUMN revoked_at timestamptz,
+				ADD COLUMN last_used_at timestamptz`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add scopes to auth_keys table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "auth_keys" 
+				ADD COLUMN scopes text[] NOT NULL DEFAULT '{}'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// existing auth keys could do everything
+			stmnt = `UPDATE "auth_keys" SET scopes = $1`
+			if _, err := tx.Exec(stmnt, pq.Array([]string{"tokens:read",
+				"tokens:write", "keys:admin"})); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add request signing to auth_keys table",
+		Func: func(tx *sql.Tx) error {
+			// the signing keys are sealed with the server-side signing secret
+			stmnt := `ALTER TABLE "auth_keys" 
+				ADD COLUMN signing_key text NOT NULL DEFAULT ''`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE TABLE "auth_nonces" (
+				key_id text NOT NULL REFERENCES auth_keys(key_id) ON DELETE CASCADE,
+				nonce text NOT NULL,
+				expires_at timestamptz NOT NULL,
+				PRIMARY KEY(key_id, nonce)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX auth_nonces_expires_at_idx ON "auth_nonces"(expires_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create audit_events table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "audit_events" (
+				event_id uuid PRIMARY KEY,
+				actor text NOT NULL DEFAULT '',
+				auth_method text NOT NULL DEFAULT '',
+				action text NOT NULL,
+				target text NOT NULL DEFAULT '',
+				status int NOT NULL,
+				request_id text NOT NULL DEFAULT '',
+				ip_address text NOT NULL DEFAULT '',
+				-- events in the same transaction are still ordered
+				created_at timestamptz NOT NULL DEFAULT clock_timestamp()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS audit_events_created_at_idx 
+				ON "audit_events" (created_at DESC, event_id DESC)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// auth keys that can manage auth keys can read the audit log
+			stmnt = `UPDATE "auth_keys" SET scopes = array_append(scopes, 'audit:read') 
+				WHERE 'keys:admin' = ANY(scopes)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add metadata and labels to tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "tokens" 
+				ADD COLUMN metadata jsonb,
+				ADD COLUMN labels text[] NOT NULL DEFAULT '{}'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS tokens_labels_idx 
+				ON "tokens" USING GIN (labels)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create campaigns table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "campaigns" (
+				id uuid PRIMARY KEY,
+				name text NOT NULL,
+				-- default token time-to-live in nanoseconds
+				token_ttl bigint NOT NULL DEFAULT 0,
+				max_redemptions int NOT NULL DEFAULT 0,
+				starts_at timestamptz,
+				ends_at timestamptz,
+				max_tokens int NOT NULL DEFAULT 0,
+				token_count int NOT NULL DEFAULT 0,
+				disabled boolean NOT NULL DEFAULT FALSE,
+				created_at timestamptz NOT NULL DEFAULT now(),
+				updated_at timestamptz NOT NULL DEFAULT now()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `ALTER TABLE "tokens" 
+				ADD COLUMN campaign_id uuid REFERENCES campaigns(id)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS tokens_campaign_id_idx 
+				ON "tokens" (campaign_id)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Add not_before to tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `ALTER TABLE "tokens" ADD COLUMN not_before timestamptz`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create rate_limits table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "rate_limits" (
+				key text PRIMARY KEY,
+				tokens double precision NOT NULL,
+				updated_at timestamptz NOT NULL DEFAULT now(),
+				expires_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS rate_limits_expires_at_idx 
+				ON "rate_limits" (expires_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create lockouts table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "lockouts" (
+				client text PRIMARY KEY,
+				failures int NOT NULL DEFAULT 0,
+				locked_until timestamptz,
+				failed_at timestamptz
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// the forgotten lockouts are removed on every failed attempt
+			stmnt = `CREATE INDEX IF NOT EXISTS lockouts_failed_at_idx 
+				ON "lockouts" (failed_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Store token timestamps as timestamptz",
+		Func: func(tx *sql.Tx) error {
+			// the timestamps were set with now() in the session time
+			// zone, which is also the time zone used for converting them
+			stmnt := `ALTER TABLE "tokens" 
+				ALTER COLUMN redeemed_at TYPE timestamptz,
+				ALTER COLUMN updated_at TYPE timestamptz,
+				ALTER COLUMN created_at TYPE timestamptz`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
 	// Add new migration
 )
+
+// checkKeyIDs returns an error if some of the auth keys share a key id
+func checkKeyIDs(authKeys []authn.AuthKey) error {
+	counts := map[authn.KeyID]int{}
+	for _, authKey := range authKeys {
+		keyID := authKey.ID()
+		if keyID == authn.NilKeyID {
+			return errors.New("some auth keys are too short to have " +
+				"a key id, delete them before migrating")
+		}
+
+		counts[keyID]++
+	}
+
+	var dups []string
+	for keyID, count := range counts {
+		if count > 1 {
+			dups = append(dups, string(keyID))
+		}
+	}
+
+	if len(dups) == 0 {
+		return nil
+	}
+
+	sort.Strings(dups)
+	return errors.Errorf("auth keys share the key ids %s, delete all "+
+		"but one of the auth keys starting with each key id before migrating",
+		strings.Join(dups, ", "))
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/ratelimit"
+)
+
+// RateLimitRepository is a rate limit repository that uses postgres as backend
+type RateLimitRepository struct {
+	db *sql.DB
+}
+
+var _ ratelimit.Repository = (*RateLimitRepository)(nil)
+
+// NewRateLimitRepository returns a new rate limit repository
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// TakeToken atomically refills and takes a token from the bucket of the
+// key using the db clock, so the buckets are consistent across instances
+func (repo *RateLimitRepository) TakeToken(ctx context.Context, key string, cfg ratelimit.Config) (bool, error) {
+	// an expired bucket is considered full, the update is
+	// skipped and no row is returned when the bucket is empty
+	stmnt := `insert into rate_limits (key, tokens, updated_at, expires_at) 
+		values ($1, $2::float8 - 1, now(), now() + $4::float8 * interval '1 second')
+		on conflict (key) do update set 
+			tokens = (case when rate_limits.expires_at <= now() then $2::float8 
+				else least($2::float8, rate_limits.tokens + 
+					extract(epoch from now() - rate_limits.updated_at) * $3::float8) end) - 1,
+			updated_at = excluded.updated_at,
+			expires_at = excluded.expires_at
+		where (case when rate_limits.expires_at <= now() then $2::float8 
+			else least($2::float8, rate_limits.tokens + 
+				extract(epoch from now() - rate_limits.updated_at) * $3::float8) end) >= 1
+		returning key`
+	err := repo.db.QueryRowContext(ctx, stmnt, key, cfg.Burst,
+		cfg.Rate, cfg.ExpiresIn.Seconds()).Scan(&key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "upsert bucket")
+	}
+
+	return true, nil
+}
+
+// DeleteExpiredBuckets removes the expired buckets from the db
+func (repo *RateLimitRepository) DeleteExpiredBuckets(ctx context.Context) error {
+	stmnt := `delete from rate_limits where expires_at <= now()`
+	_, err := repo.db.ExecContext(ctx, stmnt)
+	return errors.Wrap(err, "delete expired buckets")
+}
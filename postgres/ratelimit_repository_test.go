@@ -0,0 +1,11 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestRateLimitRepository(t *testing.T) {
+	storagetest.RateLimitRepository(t, storagetest.Postgres)
+}
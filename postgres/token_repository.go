@@ -3,7 +3,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"github.com/stevenferrer/invitesvc/token"
@@ -22,19 +26,152 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 }
 
 // CreateToken creates a new token and saves it to database
-func (repo *TokenRepository) CreateToken(ctx context.Context, id token.ID) error {
-	stmnt := `insert into tokens (token) values ($1)`
-	_, err := repo.db.ExecContext(ctx, stmnt, id)
-	return errors.Wrap(err, "insert token")
+func (repo *TokenRepository) CreateToken(ctx context.Context, tk *token.Token) error {
+	return repo.CreateTokens(ctx, []*token.Token{tk})
+}
+
+// insertBatchSize is the number of rows per insert statement
+const insertBatchSize = 1000
+
+// CreateTokens creates new tokens in a single transaction
+func (repo *TokenRepository) CreateTokens(ctx context.Context, tks []*token.Token) (err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = incrCampaignTokens(ctx, tx, tks)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(tks); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(tks) {
+			end = len(tks)
+		}
+
+		stmnt, args, err := insertTokensStmnt(tks[start:end])
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmnt, args...)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return token.ErrDuplicateToken
+			}
+
+			return errors.Wrap(err, "insert tokens")
+		}
+	}
+
+	err = tx.Commit()
+	return errors.Wrap(err, "commit tx")
+}
+
+// incrCampaignTokens increments the token count of the campaigns
+// of the tokens, the update is conditional so that concurrent
+// generation cannot exceed the campaign token limit
+func incrCampaignTokens(ctx context.Context, tx *sql.Tx, tks []*token.Token) error {
+	counts := make(map[token.CampaignID]int)
+	for _, tk := range tks {
+		if tk.CampaignID != token.NilCampaignID {
+			counts[tk.CampaignID]++
+		}
+	}
+
+	for id, n := range counts {
+		stmnt := `update campaigns set token_count=token_count+$2, 
+			updated_at=now() where id=$1 
+			and (max_tokens = 0 or token_count+$2 <= max_tokens)`
+		res, err := tx.ExecContext(ctx, stmnt, id, n)
+		if err != nil {
+			return errors.Wrap(err, "update campaign")
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "rows affected")
+		}
+
+		if updated > 0 {
+			continue
+		}
+
+		// nothing was updated, find out why
+		var exists bool
+		stmnt = `select exists(select 1 from campaigns where id=$1)`
+		err = tx.QueryRowContext(ctx, stmnt, id).Scan(&exists)
+		if err != nil {
+			return errors.Wrap(err, "query campaign")
+		}
+
+		if !exists {
+			return token.ErrCampaignNotFound
+		}
+
+		return token.ErrCampaignFull
+	}
+
+	return nil
+}
+
+// insertTokensStmnt returns a multi-row insert statement and its args
+func insertTokensStmnt(tks []*token.Token) (string, []interface{}, error) {
+	const cols = 7
+	var sb strings.Builder
+	sb.WriteString(`insert into tokens (token, max_redemptions, 
+		expires_at, not_before, metadata, labels, campaign_id) values `)
+	args := make([]interface{}, 0, len(tks)*cols)
+	for i, tk := range tks {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		metadata, err := marshalJSON(tk.Metadata)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "marshal metadata")
+		}
+
+		n := i * cols
+		fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+		args = append(args, tk.ID, tk.MaxRedemptions, tk.ExpiresAt, tk.NotBefore,
+			metadata, labelsArray(tk.Labels), nullCampaignID(tk.CampaignID))
+	}
+
+	return sb.String(), args, nil
+}
+
+// labelsArray returns the labels as a text array, nil
+// labels are stored as an empty array instead of NULL
+func labelsArray(labels []string) pq.StringArray {
+	if labels == nil {
+		return pq.StringArray{}
+	}
+
+	return pq.StringArray(labels)
+}
+
+// nullCampaignID returns nil if the campaign id is empty
+func nullCampaignID(id token.CampaignID) interface{} {
+	if id == token.NilCampaignID {
+		return nil
+	}
+
+	return id
 }
 
 // GetToken retrieves a token from the database
 func (repo *TokenRepository) GetToken(ctx context.Context, id token.ID) (*token.Token, error) {
-	stmnt := `select token, disabled, redeemed_at, 
-		created_at from tokens where token = $1`
-	var tk token.Token
-	err := repo.db.QueryRowContext(ctx, stmnt, id).Scan(
-		&tk.ID, &tk.Disabled, &tk.RedeemedAt, &tk.CreatedAt)
+	stmnt := `select ` + tokenCols + ` from ` + tokensFrom + ` where t.token = $1`
+	tk, err := scanToken(repo.db.QueryRowContext(ctx, stmnt, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, token.ErrTokenNotFound
@@ -43,32 +180,179 @@ func (repo *TokenRepository) GetToken(ctx context.Context, id token.ID) (*token.
 		return nil, errors.Wrap(err, "query token")
 	}
 
+	return tk, nil
+}
+
+// tokensFrom is the tokens table joined with the campaigns
+// table, used for selecting the campaign state of the tokens
+const tokensFrom = `tokens t left join campaigns c on c.id = t.campaign_id`
+
+// tokenCols are the token columns in the order expected by scanToken
+const tokenCols = `t.token, t.disabled, t.max_redemptions, t.redemptions, 
+	t.expires_at, t.not_before, t.revoked_at, t.revoke_reason, t.metadata, t.labels, 
+	coalesce(t.campaign_id::text, ''), coalesce(c.disabled, FALSE), 
+	t.redeemed_at, t.created_at`
+
+// scanner is implemented by sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanToken scans a token from a row selected with tokenCols
+func scanToken(row scanner) (*token.Token, error) {
+	var (
+		tk       token.Token
+		metadata []byte
+		labels   pq.StringArray
+	)
+	err := row.Scan(&tk.ID, &tk.Disabled, &tk.MaxRedemptions,
+		&tk.Redemptions, &tk.ExpiresAt, &tk.NotBefore, &tk.RevokedAt,
+		&tk.RevokeReason, &metadata, &labels, &tk.CampaignID,
+		&tk.CampaignDisabled, &tk.RedeemedAt, &tk.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unmarshalJSON(metadata, &tk.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal metadata")
+	}
+	tk.Labels = labels
+
 	return &tk, nil
 }
 
-// ListToken retrieves tokens from the database
-func (repo *TokenRepository) ListTokens(ctx context.Context) ([]*token.Token, error) {
-	stmnt := `select token, disabled, redeemed_at, created_at 
-		from tokens order by created_at`
-	rows, err := repo.db.QueryContext(ctx, stmnt)
+// statusConds are the conditions for each token status, the
+// conditions must be consistent with token.Token.Status
+var statusConds = map[token.Status]string{
+	token.StatusRevoked: `t.revoked_at is not null`,
+	token.StatusDisabled: `t.revoked_at is null 
+		and (t.disabled or coalesce(c.disabled, FALSE))`,
+	token.StatusRedeemed: `t.revoked_at is null 
+		and not (t.disabled or coalesce(c.disabled, FALSE)) 
+		and t.redemptions >= t.max_redemptions`,
+	token.StatusExpired: `t.revoked_at is null 
+		and not (t.disabled or coalesce(c.disabled, FALSE)) 
+		and t.redemptions < t.max_redemptions 
+		and t.expires_at <= now()`,
+	token.StatusPending: `t.revoked_at is null 
+		and not (t.disabled or coalesce(c.disabled, FALSE)) 
+		and t.redemptions < t.max_redemptions 
+		and (t.expires_at is null or t.expires_at > now()) 
+		and t.not_before > now()`,
+	token.StatusActive: `t.revoked_at is null 
+		and not (t.disabled or coalesce(c.disabled, FALSE)) 
+		and (t.expires_at is null or t.expires_at > now()) 
+		and (t.not_before is null or t.not_before <= now()) 
+		and t.redemptions < t.max_redemptions`,
+}
+
+// ListToken retrieves tokens matching the query from the database
+func (repo *TokenRepository) ListTokens(ctx context.Context, query token.ListQuery) ([]*token.Token, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	// arg adds an argument and returns its placeholder
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.Status != "" {
+		cond, ok := statusConds[query.Status]
+		if !ok {
+			return nil, errors.Errorf("unknown status %q", query.Status)
+		}
+		conds = append(conds, cond)
+	}
+
+	if query.CreatedAfter != nil {
+		conds = append(conds, "t.created_at >= "+arg(*query.CreatedAfter))
+	}
+
+	if query.CreatedBefore != nil {
+		conds = append(conds, "t.created_at < "+arg(*query.CreatedBefore))
+	}
+
+	if len(query.Labels) > 0 {
+		conds = append(conds, "t.labels @> "+arg(pq.StringArray(query.Labels)))
+	}
+
+	if query.Campaign != token.NilCampaignID {
+		conds = append(conds, "t.campaign_id::text = "+arg(query.Campaign))
+	}
+
+	order, cmp := "asc", ">"
+	if query.Sort == token.SortDesc {
+		order, cmp = "desc", "<"
+	}
+
+	if query.After != nil {
+		conds = append(conds, fmt.Sprintf("(t.created_at, t.token) %s (%s, %s)",
+			cmp, arg(query.After.CreatedAt), arg(query.After.ID)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`select ` + tokenCols + ` from ` + tokensFrom)
+	if len(conds) > 0 {
+		sb.WriteString(" where " + strings.Join(conds, " and "))
+	}
+	fmt.Fprintf(&sb, " order by t.created_at %s, t.token %s", order, order)
+	if query.Limit > 0 {
+		sb.WriteString(" limit " + arg(query.Limit))
+	}
+
+	rows, err := repo.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "query tokens")
 	}
+	defer rows.Close()
 
 	tokens := make([]*token.Token, 0, 10)
 	for rows.Next() {
-		var tk token.Token
-		err = rows.Scan(&tk.ID, &tk.Disabled, &tk.RedeemedAt, &tk.CreatedAt)
+		tk, err := scanToken(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "scan row")
 		}
 
-		tokens = append(tokens, &tk)
+		tokens = append(tokens, tk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
 	}
 
 	return tokens, nil
 }
 
+// CountTokens retrieves the number of tokens per status from the database
+func (repo *TokenRepository) CountTokens(ctx context.Context) (map[token.Status]int, error) {
+	cols := make([]string, 0, len(token.Statuses))
+	for _, status := range token.Statuses {
+		cols = append(cols, fmt.Sprintf("count(*) filter (where %s)", statusConds[status]))
+	}
+
+	stmnt := "select " + strings.Join(cols, ", ") + " from " + tokensFrom
+	counts := make([]int, len(token.Statuses))
+	dest := make([]interface{}, 0, len(counts))
+	for i := range counts {
+		dest = append(dest, &counts[i])
+	}
+
+	err := repo.db.QueryRowContext(ctx, stmnt).Scan(dest...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query token counts")
+	}
+
+	summary := make(map[token.Status]int, len(counts))
+	for i, status := range token.Statuses {
+		summary[status] = counts[i]
+	}
+
+	return summary, nil
+}
+
 // SetTokenDisabled sets a token to disabled
 func (repo *TokenRepository) SetTokenDisabled(ctx context.Context, id token.ID) error {
 	tk, err := repo.GetToken(ctx, id)
@@ -82,15 +366,254 @@ func (repo *TokenRepository) SetTokenDisabled(ctx context.Context, id token.ID)
 	return errors.Wrap(err, "update token")
 }
 
-// SetTokenRedeemed sets a token to redeemed
-func (repo *TokenRepository) SetTokenRedeemed(ctx context.Context, id token.ID) error {
-	tk, err := repo.GetToken(ctx, id)
+// SetTokenEnabled sets a token to not disabled
+func (repo *TokenRepository) SetTokenEnabled(ctx context.Context, id token.ID) error {
+	return repo.updateToken(ctx, id, `disabled=FALSE`)
+}
+
+// SetTokenExpiration sets the token expiration
+func (repo *TokenRepository) SetTokenExpiration(ctx context.Context, id token.ID, expiresAt *time.Time) error {
+	return repo.updateToken(ctx, id, `expires_at=$2`, expiresAt)
+}
+
+// SetTokenRevoked sets a token to revoked
+func (repo *TokenRepository) SetTokenRevoked(ctx context.Context, id token.ID, reason string) error {
+	return repo.updateToken(ctx, id, `revoked_at=now(), revoke_reason=$2`, reason)
+}
+
+// updateToken updates a token with the set clause, the
+// token id is always the first argument of the statement
+func (repo *TokenRepository) updateToken(ctx context.Context, id token.ID, set string, args ...interface{}) error {
+	stmnt := `update tokens set ` + set + `, updated_at=now() where token=$1`
+	res, err := repo.db.ExecContext(ctx, stmnt, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return errors.Wrap(err, "update token")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return token.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// RedeemToken sets a token to redeemed and inserts the redemption
+// record. The update is conditional so that concurrent redemptions
+// cannot exceed the max redemptions.
+func (repo *TokenRepository) RedeemToken(ctx context.Context, r *token.Redemption) error {
+	metadata, err := marshalJSON(r.Metadata)
+	if err != nil {
+		return errors.Wrap(err, "marshal metadata")
+	}
+
+	stmnt := `with redeemed as (
+			update tokens set redemptions=redemptions+1, 
+			redeemed_at=now(), updated_at=now()
+			where token=$1 and revoked_at is null 
+			and disabled=FALSE 
+			and redemptions < max_redemptions
+			and (expires_at is null or expires_at > now())
+			and (not_before is null or not_before <= now())
+			and not exists (select 1 from campaigns c 
+				where c.id = tokens.campaign_id and c.disabled)
+			returning token
+		)
+		insert into redemptions (id, token, user_id, email, 
+			metadata, ip_address, user_agent)
+		select $2::uuid, token, $3::text, $4::text, $5::jsonb, 
+			$6::text, $7::text from redeemed
+		returning created_at`
+	err = repo.db.QueryRowContext(ctx, stmnt, r.Token, r.ID, r.UserID,
+		r.Email, metadata, r.IPAddress, r.UserAgent).Scan(&r.CreatedAt)
+	if err == nil {
+		return nil
+	}
+
+	if err != sql.ErrNoRows {
+		return errors.Wrap(err, "update token")
+	}
+
+	// nothing was updated, find out which rule failed
+	tk, err := repo.GetToken(ctx, r.Token)
 	if err != nil {
 		return errors.Wrap(err, "get token")
 	}
 
-	stmnt := `update tokens set redeemed_at=now(), 
-		updated_at=now() where token=$1`
-	_, err = repo.db.ExecContext(ctx, stmnt, tk.ID)
-	return errors.Wrap(err, "update token")
+	err = tk.Validate()
+	if err != nil {
+		return err
+	}
+
+	return errors.Errorf("token %s was not redeemed", r.Token)
+}
+
+// ListRedemptions retrieves the redemption records of a token
+func (repo *TokenRepository) ListRedemptions(ctx context.Context, id token.ID) ([]*token.Redemption, error) {
+	stmnt := `select id, token, user_id, email, metadata, ip_address, 
+		user_agent, created_at from redemptions 
+		where token = $1 order by created_at`
+	rows, err := repo.db.QueryContext(ctx, stmnt, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "query redemptions")
+	}
+	defer rows.Close()
+
+	redemptions := make([]*token.Redemption, 0, 10)
+	for rows.Next() {
+		var (
+			r        token.Redemption
+			metadata []byte
+		)
+		err = rows.Scan(&r.ID, &r.Token, &r.UserID, &r.Email, &metadata,
+			&r.IPAddress, &r.UserAgent, &r.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+
+		err = unmarshalJSON(metadata, &r.Metadata)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal metadata")
+		}
+
+		redemptions = append(redemptions, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return redemptions, nil
+}
+
+// campaignCols are the campaign columns in the order expected by scanCampaign
+const campaignCols = `id, name, token_ttl, max_redemptions, starts_at, 
+	ends_at, max_tokens, token_count, disabled, created_at, updated_at`
+
+// scanCampaign scans a campaign from a row selected with campaignCols
+func scanCampaign(row scanner) (*token.Campaign, error) {
+	var c token.Campaign
+	err := row.Scan(&c.ID, &c.Name, &c.TTL, &c.MaxRedemptions,
+		&c.StartsAt, &c.EndsAt, &c.MaxTokens, &c.Tokens,
+		&c.Disabled, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// CreateCampaign creates a new campaign and saves it to database
+func (repo *TokenRepository) CreateCampaign(ctx context.Context, c *token.Campaign) error {
+	stmnt := `insert into campaigns (id, name, token_ttl, max_redemptions, 
+			starts_at, ends_at, max_tokens) 
+		values ($1, $2, $3, $4, $5, $6, $7) 
+		returning created_at, updated_at`
+	err := repo.db.QueryRowContext(ctx, stmnt, c.ID, c.Name, int64(c.TTL),
+		c.MaxRedemptions, c.StartsAt, c.EndsAt, c.MaxTokens).
+		Scan(&c.CreatedAt, &c.UpdatedAt)
+	return errors.Wrap(err, "insert campaign")
+}
+
+// GetCampaign retrieves a campaign from the database
+func (repo *TokenRepository) GetCampaign(ctx context.Context, id token.CampaignID) (*token.Campaign, error) {
+	stmnt := `select ` + campaignCols + ` from campaigns where id::text = $1`
+	c, err := scanCampaign(repo.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, token.ErrCampaignNotFound
+		}
+
+		return nil, errors.Wrap(err, "query campaign")
+	}
+
+	return c, nil
+}
+
+// ListCampaigns retrieves all campaigns from the database
+func (repo *TokenRepository) ListCampaigns(ctx context.Context) ([]*token.Campaign, error) {
+	stmnt := `select ` + campaignCols + ` from campaigns order by created_at, id`
+	rows, err := repo.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query campaigns")
+	}
+	defer rows.Close()
+
+	campaigns := make([]*token.Campaign, 0, 10)
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+
+		campaigns = append(campaigns, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates the campaign settings
+func (repo *TokenRepository) UpdateCampaign(ctx context.Context, c *token.Campaign) error {
+	return repo.updateCampaign(ctx, c.ID, `name=$2, token_ttl=$3, 
+		max_redemptions=$4, starts_at=$5, ends_at=$6, max_tokens=$7`,
+		c.Name, int64(c.TTL), c.MaxRedemptions, c.StartsAt, c.EndsAt, c.MaxTokens)
+}
+
+// SetCampaignDisabled sets a campaign to disabled or not disabled
+func (repo *TokenRepository) SetCampaignDisabled(ctx context.Context, id token.CampaignID, disabled bool) error {
+	return repo.updateCampaign(ctx, id, `disabled=$2`, disabled)
+}
+
+// updateCampaign updates a campaign with the set clause, the
+// campaign id is always the first argument of the statement
+func (repo *TokenRepository) updateCampaign(ctx context.Context, id token.CampaignID, set string, args ...interface{}) error {
+	stmnt := `update campaigns set ` + set + `, updated_at=now() where id::text=$1`
+	res, err := repo.db.ExecContext(ctx, stmnt, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return errors.Wrap(err, "update campaign")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return token.ErrCampaignNotFound
+	}
+
+	return nil
+}
+
+// DeleteCampaign deletes a campaign without tokens from the database
+func (repo *TokenRepository) DeleteCampaign(ctx context.Context, id token.CampaignID) error {
+	c, err := repo.GetCampaign(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "get campaign")
+	}
+
+	stmnt := `delete from campaigns where id=$1 and token_count = 0`
+	res, err := repo.db.ExecContext(ctx, stmnt, c.ID)
+	if err != nil {
+		return errors.Wrap(err, "delete campaign")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return token.ErrCampaignHasTokens
+	}
+
+	return nil
 }
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "github.com/pkg/errors"
+
+// List of errors
+var (
+	ErrInvalidConfig = errors.New("invalid rate limit config")
+)
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config is a token bucket rate limit policy
+type Config struct {
+	// Rate is the number of requests allowed per second
+	Rate float64
+	// Burst is the max number of requests allowed at once
+	Burst int
+	// ExpiresIn is the duration after which an idle bucket is removed
+	ExpiresIn time.Duration
+}
+
+// DefaultConfig is the default rate limit policy
+var DefaultConfig = Config{
+	Rate:      10,
+	Burst:     30,
+	ExpiresIn: 3 * time.Minute,
+}
+
+// Validate validates the config
+func (cfg Config) Validate() error {
+	if cfg.Rate <= 0 || cfg.Burst < 1 || cfg.ExpiresIn <= 0 {
+		return ErrInvalidConfig
+	}
+
+	return nil
+}
+
+// ParseConfig parses a comma separated list of config values, e.g.
+// rate=10,burst=30,expiry=3m. The values not in the list are taken
+// from the default config.
+func ParseConfig(s string) (Config, error) {
+	cfg := DefaultConfig
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return cfg, errors.Wrap(ErrInvalidConfig, kv)
+		}
+
+		var err error
+		switch key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]); key {
+		case "rate":
+			cfg.Rate, err = strconv.ParseFloat(value, 64)
+		case "burst":
+			cfg.Burst, err = strconv.Atoi(value)
+		case "expiry":
+			cfg.ExpiresIn, err = time.ParseDuration(value)
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return cfg, errors.Wrap(ErrInvalidConfig, kv)
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// String returns the config in the format accepted by ParseConfig
+func (cfg Config) String() string {
+	return "rate=" + strconv.FormatFloat(cfg.Rate, 'f', -1, 64) +
+		",burst=" + strconv.Itoa(cfg.Burst) +
+		",expiry=" + cfg.ExpiresIn.String()
+}
+
+// Bucket is the token bucket of a client
+type Bucket struct {
+	// Key identifies the client
+	Key string
+	// Tokens is the number of tokens left as of UpdatedAt
+	Tokens float64
+	// UpdatedAt is the time the bucket was last taken from
+	UpdatedAt time.Time
+	// ExpiresAt is the time the bucket is considered full again
+	ExpiresAt time.Time
+}
+
+// Take refills the bucket up to now and takes a token from it,
+// false is returned and the bucket is left untouched when empty
+func (b *Bucket) Take(cfg Config, now time.Time) bool {
+	tokens := float64(cfg.Burst)
+	if now.Before(b.ExpiresAt) {
+		elapsed := now.Sub(b.UpdatedAt).Seconds()
+		tokens = math.Min(tokens, b.Tokens+elapsed*cfg.Rate)
+	}
+
+	if tokens < 1 {
+		return false
+	}
+
+	b.Tokens = tokens - 1
+	b.UpdatedAt = now
+	b.ExpiresAt = now.Add(cfg.ExpiresIn)
+	return true
+}
@@ -0,0 +1,163 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/inmem"
+	"github.com/stevenferrer/invitesvc/ratelimit"
+)
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, ratelimit.DefaultConfig.Validate())
+
+	cfg := ratelimit.DefaultConfig
+	cfg.Rate = 0
+	assert.ErrorIs(t, cfg.Validate(), ratelimit.ErrInvalidConfig)
+
+	cfg = ratelimit.DefaultConfig
+	cfg.Burst = 0
+	assert.ErrorIs(t, cfg.Validate(), ratelimit.ErrInvalidConfig)
+
+	cfg = ratelimit.DefaultConfig
+	cfg.ExpiresIn = 0
+	assert.ErrorIs(t, cfg.Validate(), ratelimit.ErrInvalidConfig)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ratelimit.ParseConfig("rate=0.5, burst=5")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, cfg.Rate)
+	assert.Equal(t, 5, cfg.Burst)
+	assert.Equal(t, ratelimit.DefaultConfig.ExpiresIn, cfg.ExpiresIn)
+
+	cfg, err = ratelimit.ParseConfig(ratelimit.DefaultConfig.String())
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.DefaultConfig, cfg)
+
+	cfg, err = ratelimit.ParseConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.DefaultConfig, cfg)
+
+	for _, s := range []string{"rate", "rate=fast", "burst=0", "expiry=1", "limit=10"} {
+		_, err = ratelimit.ParseConfig(s)
+		assert.ErrorIs(t, err, ratelimit.ErrInvalidConfig, s)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	cfg := ratelimit.Config{Rate: 1, Burst: 2, ExpiresIn: time.Minute}
+	now := time.Now()
+
+	var bucket ratelimit.Bucket
+	assert.True(t, bucket.Take(cfg, now))
+	assert.True(t, bucket.Take(cfg, now))
+	assert.False(t, bucket.Take(cfg, now))
+	assert.Equal(t, float64(0), bucket.Tokens)
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(time.Second)
+		assert.True(t, bucket.Take(cfg, now))
+		assert.False(t, bucket.Take(cfg, now))
+
+		// refill is capped at burst
+		now = now.Add(10 * time.Second)
+		assert.True(t, bucket.Take(cfg, now))
+		assert.True(t, bucket.Take(cfg, now))
+		assert.False(t, bucket.Take(cfg, now))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		cfg := ratelimit.Config{Rate: 0.001, Burst: 1, ExpiresIn: time.Second}
+		var bucket ratelimit.Bucket
+		assert.True(t, bucket.Take(cfg, now))
+		assert.False(t, bucket.Take(cfg, now))
+
+		now = now.Add(time.Second)
+		assert.True(t, bucket.Take(cfg, now))
+	})
+}
+
+func TestStore(t *testing.T) {
+	db, err := memdb.NewMemDB(inmem.Schema())
+	require.NoError(t, err)
+
+	cfg := ratelimit.Config{Rate: 0.001, Burst: 3, ExpiresIn: time.Minute}
+	store := ratelimit.NewStore(inmem.NewRateLimitRepository(db), "redeem", cfg)
+
+	for i := 0; i < 3; i++ {
+		allow, err := store.Allow("192.0.2.1")
+		require.NoError(t, err)
+		assert.True(t, allow)
+	}
+
+	allow, err := store.Allow("192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, allow)
+
+	// buckets are per client
+	allow, err = store.Allow("192.0.2.2")
+	require.NoError(t, err)
+	assert.True(t, allow)
+
+	t.Run("shared repository", func(t *testing.T) {
+		// another instance using the same repository shares the limits
+		other := ratelimit.NewStore(inmem.NewRateLimitRepository(db), "redeem", cfg)
+		allow, err := other.Allow("192.0.2.1")
+		require.NoError(t, err)
+		assert.False(t, allow)
+
+		// stores with different names have separate buckets
+		other = ratelimit.NewStore(inmem.NewRateLimitRepository(db), "validate", cfg)
+		allow, err = other.Allow("192.0.2.1")
+		require.NoError(t, err)
+		assert.True(t, allow)
+	})
+
+	t.Run("memory store", func(t *testing.T) {
+		store := ratelimit.NewMemoryStore(cfg)
+		for i := 0; i < 3; i++ {
+			allow, err := store.Allow("192.0.2.1")
+			require.NoError(t, err)
+			assert.True(t, allow)
+		}
+
+		allow, err := store.Allow("192.0.2.1")
+		require.NoError(t, err)
+		assert.False(t, allow)
+	})
+
+	t.Run("failed cleanup", func(t *testing.T) {
+		repo := failingCleanupRepository{inmem.NewRateLimitRepository(db)}
+		cfg := ratelimit.Config{Rate: 0.001, Burst: 1, ExpiresIn: time.Nanosecond}
+
+		var buf bytes.Buffer
+		store := ratelimit.NewStore(repo, "cleanup", cfg,
+			ratelimit.WithLogger(zerolog.New(&buf)))
+
+		// the token is taken even when the cleanup fails
+		time.Sleep(time.Millisecond)
+		allow, err := store.Allow("192.0.2.1")
+		require.NoError(t, err)
+		assert.True(t, allow)
+		assert.Contains(t, buf.String(), "cleanup rate limits")
+	})
+}
+
+// failingCleanupRepository is a rate limit repository
+// that fails to remove the expired buckets
+type failingCleanupRepository struct {
+	ratelimit.Repository
+}
+
+func (failingCleanupRepository) DeleteExpiredBuckets(context.Context) error {
+	return errors.New("delete failed")
+}
@@ -0,0 +1,14 @@
+package ratelimit
+
+import "context"
+
+// Repository is a rate limit bucket repository, implementations
+// must take tokens atomically so that the limits are shared
+// by all instances using the same backend
+type Repository interface {
+	// TakeToken takes a token from the bucket of the key, false
+	// is returned when the bucket is empty
+	TakeToken(ctx context.Context, key string, cfg Config) (bool, error)
+	// DeleteExpiredBuckets removes the expired buckets
+	DeleteExpiredBuckets(context.Context) error
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// store is a rate limiter store backed by a repository
+type store struct {
+	repo   Repository
+	name   string
+	cfg    Config
+	logger zerolog.Logger
+
+	mu sync.Mutex
+	// cleanedAt is the last time the expired buckets were removed
+	cleanedAt time.Time
+}
+
+var _ middleware.RateLimiterStore = (*store)(nil)
+
+// Option is a rate limiter store option
+type Option func(*store)
+
+// WithLogger sets the logger of the errors that don't fail the requests
+func WithLogger(logger zerolog.Logger) Option {
+	return func(s *store) {
+		s.logger = logger
+	}
+}
+
+// NewStore returns a rate limiter store that keeps the buckets in the
+// repository, the name separates the buckets of stores sharing a repository
+func NewStore(repo Repository, name string, cfg Config, opts ...Option) middleware.RateLimiterStore {
+	s := &store{
+		repo:      repo,
+		name:      name,
+		cfg:       cfg,
+		logger:    zerolog.New(os.Stderr).With().Timestamp().Logger(),
+		cleanedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NewMemoryStore returns a rate limiter store that keeps the buckets in memory,
+// limits are not shared between instances and are reset on restart
+func NewMemoryStore(cfg Config) middleware.RateLimiterStore {
+	return middleware.NewRateLimiterMemoryStoreWithConfig(
+		middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(cfg.Rate),
+			Burst:     cfg.Burst,
+			ExpiresIn: cfg.ExpiresIn,
+		},
+	)
+}
+
+// Allow takes a token from the bucket of the identifier and returns
+// false when the bucket is empty, it implements middleware.RateLimiterStore
+func (s *store) Allow(identifier string) (bool, error) {
+	// the middleware does not pass the request context
+	ctx := context.Background()
+	// the expired buckets are only a waste of space,
+	// don't fail the request when removing them fails
+	err := s.cleanup(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Str("store", s.name).Msg("cleanup rate limits")
+	}
+
+	allow, err := s.repo.TakeToken(ctx, s.name+":"+identifier, s.cfg)
+	if err != nil {
+		return false, errors.Wrap(err, "take token")
+	}
+
+	return allow, nil
+}
+
+// cleanup removes the expired buckets at most once every expiry period
+func (s *store) cleanup(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.cleanedAt) < s.cfg.ExpiresIn {
+		return nil
+	}
+
+	// a failed cleanup is retried in the next period
+	// instead of on every request
+	s.cleanedAt = time.Now()
+	err := s.repo.DeleteExpiredBuckets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "delete expired buckets")
+	}
+
+	return nil
+}
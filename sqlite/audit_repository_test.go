@@ -0,0 +1,11 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestAuditRepository(t *testing.T) {
+	storagetest.AuditRepository(t, storagetest.SQLite)
+}
@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/authn"
+)
+
+// AuthRepository is an auth repository that uses sqlite as backend
+type AuthRepository struct {
+	db *sql.DB
+}
+
+var _ authn.Repository = (*AuthRepository)(nil)
+
+// NewAuthRepository retuns a new auth repository
+func NewAuthRepository(db *sql.DB) *AuthRepository {
+	return &AuthRepository{db: db}
+}
+
+// CreateAuthKey inserts an auth record into the db
+func (repo *AuthRepository) CreateAuthKey(ctx context.Context, auth *authn.Auth) error {
+	stmnt, args, err := insertAuthKeyStmnt(auth)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(ctx, stmnt, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return authn.ErrDuplicateAuthKey
+		}
+
+		return errors.Wrap(err, "insert auth key")
+	}
+
+	return nil
+}
+
+// CreateInitialAuthKey inserts an auth record into the db only
+// when there are no auth records yet
+func (repo *AuthRepository) CreateInitialAuthKey(ctx context.Context, auth *authn.Auth) (err error) {
+	stmnt, args, err := insertAuthKeyStmnt(auth)
+	if err != nil {
+		return err
+	}
+
+	// the insert is a single statement so that the
+	// check cannot race with other inserts
+	stmnt += ` where not exists (select 1 from auth_keys)`
+	res, err := repo.db.ExecContext(ctx, stmnt, args...)
+	if err != nil {
+		return errors.Wrap(err, "insert auth key")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return authn.ErrAuthKeysExist
+	}
+
+	return nil
+}
+
+// insertAuthKeyStmnt returns the insert statement and args of an
+// auth record, the values are selected so that conditions can be
+// appended to the statement
+func insertAuthKeyStmnt(auth *authn.Auth) (string, []interface{}, error) {
+	scopes := make([]string, 0, len(auth.Scopes))
+	for _, scope := range auth.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	b, err := json.Marshal(scopes)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "marshal scopes")
+	}
+
+	stmnt := `insert into auth_keys (key_id, key_hash, salt, name, owner,
+		scopes, signing_key, expires_at, created_at)
+		select ?, ?, ?, ?, ?, ?, ?, ?, ?`
+	return stmnt, []interface{}{auth.ID, auth.Hash, auth.Salt, auth.Name,
+		auth.Owner, string(b), auth.SigningKey, nullTime(auth.ExpiresAt),
+		formatTime(time.Now())}, nil
+}
+
+// authCols are the auth key columns in the order expected by scanAuth
+const authCols = `key_id, key_hash, salt, name, owner, scopes,
+	signing_key, expires_at, revoked_at, last_used_at, created_at`
+
+// scanAuth scans an auth record from a row selected with authCols
+func scanAuth(row scanner) (*authn.Auth, error) {
+	var (
+		auth   authn.Auth
+		scopes []byte
+	)
+	err := row.Scan(&auth.ID, &auth.Hash, &auth.Salt, &auth.Name,
+		&auth.Owner, &scopes, &auth.SigningKey, scanTime(&auth.ExpiresAt), scanTime(&auth.RevokedAt),
+		scanTime(&auth.LastUsedAt), scanTime(&auth.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Scopes = make([]authn.Scope, 0)
+	err = json.Unmarshal(scopes, &auth.Scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal scopes")
+	}
+
+	return &auth, nil
+}
+
+// GetAuthKey retrieves an auth record from the db
+func (repo *AuthRepository) GetAuthKey(ctx context.Context, id authn.KeyID) (*authn.Auth, error) {
+	stmnt := `select ` + authCols + ` from auth_keys where key_id=?1`
+	auth, err := scanAuth(repo.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, authn.ErrAuthKeyNotFound
+		}
+
+		return nil, errors.Wrap(err, "query auth key")
+	}
+
+	return auth, nil
+}
+
+// ListAuthKeys retrieves all auth records from the db
+func (repo *AuthRepository) ListAuthKeys(ctx context.Context) ([]*authn.Auth, error) {
+	stmnt := `select ` + authCols + ` from auth_keys order by created_at, key_id`
+	rows, err := repo.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query auth keys")
+	}
+	defer rows.Close()
+
+	auths := make([]*authn.Auth, 0, 10)
+	for rows.Next() {
+		auth, err := scanAuth(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+
+		auths = append(auths, auth)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return auths, nil
+}
+
+// SetAuthKeyRevoked sets an auth key to revoked
+func (repo *AuthRepository) SetAuthKeyRevoked(ctx context.Context, id authn.KeyID) error {
+	return repo.updateAuthKey(ctx, id, `revoked_at=?2`)
+}
+
+// SetAuthKeyUsed sets the last used timestamp of an auth key to now
+func (repo *AuthRepository) SetAuthKeyUsed(ctx context.Context, id authn.KeyID) error {
+	return repo.updateAuthKey(ctx, id, `last_used_at=?2`)
+}
+
+// CreateNonce inserts a request nonce into the db, expired nonces are removed
+func (repo *AuthRepository) CreateNonce(ctx context.Context, nonce *authn.Nonce) error {
+	stmnt := `delete from auth_nonces where expires_at < ?1`
+	_, err := repo.db.ExecContext(ctx, stmnt, formatTime(time.Now()))
+	if err != nil {
+		return errors.Wrap(err, "delete expired nonces")
+	}
+
+	stmnt = `insert into auth_nonces (key_id, nonce, expires_at) values (?1, ?2, ?3)`
+	_, err = repo.db.ExecContext(ctx, stmnt, nonce.KeyID, nonce.Value,
+		formatTime(nonce.ExpiresAt))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return authn.ErrNonceReused
+		}
+
+		return errors.Wrap(err, "insert nonce")
+	}
+
+	return nil
+}
+
+// updateAuthKey updates an auth key with the set clause, the key id
+// and the current time are always the arguments of the statement
+func (repo *AuthRepository) updateAuthKey(ctx context.Context, id authn.KeyID, set string) error {
+	stmnt := `update auth_keys set ` + set + ` where key_id=?1`
+	res, err := repo.db.ExecContext(ctx, stmnt, id, formatTime(time.Now()))
+	if err != nil {
+		return errors.Wrap(err, "update auth key")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return authn.ErrAuthKeyNotFound
+	}
+
+	return nil
+}
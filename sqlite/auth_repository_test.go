@@ -0,0 +1,11 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestAuthRepository(t *testing.T) {
+	storagetest.AuthRepository(t, storagetest.SQLite)
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import "encoding/json"
+
+// marshalJSON marshals v into a json text value, nil values are
+// marshalled into nil so that they are stored as NULL
+func marshalJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(b) == "null" {
+		return nil, nil
+	}
+
+	return string(b), nil
+}
+
+// unmarshalJSON unmarshals a json value into v, NULL values are ignored
+func unmarshalJSON(b []byte, v interface{}) error {
+	if b == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// marshalArray marshals a string slice into a json array, nil
+// slices are stored as an empty array instead of NULL
+func marshalArray(v []string) (string, error) {
+	if v == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(v)
+	return string(b), err
+}
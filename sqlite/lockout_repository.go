@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/invitesvc/lockout"
+)
+
+// LockoutRepository is a lockout repository that uses sqlite as backend
+type LockoutRepository struct {
+	db *sql.DB
+}
+
+var _ lockout.Repository = (*LockoutRepository)(nil)
+
+// NewLockoutRepository returns a new lockout repository
+func NewLockoutRepository(db *sql.DB) *LockoutRepository {
+	return &LockoutRepository{db: db}
+}
+
+// lockoutCols are the lockout columns in the order expected by scanLockout
+const lockoutCols = `client, failures, locked_until, failed_at`
+
+// scanLockout scans a lockout from a row selected with lockoutCols
+func scanLockout(row scanner) (*lockout.Lockout, error) {
+	var l lockout.Lockout
+	err := row.Scan(&l.Client, &l.Failures, scanTime(&l.LockedUntil), scanTime(&l.FailedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// GetLockout retrieves the lockout of a client from the db
+func (repo *LockoutRepository) GetLockout(ctx context.Context, client string) (*lockout.Lockout, error) {
+	stmnt := `select ` + lockoutCols + ` from lockouts where client=?1`
+	l, err := scanLockout(repo.db.QueryRowContext(ctx, stmnt, client))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, lockout.ErrLockoutNotFound
+		}
+
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return l, nil
+}
+
+// ListLockouts retrieves the lockouts from the db, last failed first
+func (repo *LockoutRepository) ListLockouts(ctx context.Context) ([]*lockout.Lockout, error) {
+	stmnt := `select ` + lockoutCols + ` from lockouts order by failed_at desc, client`
+	rows, err := repo.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	lockouts := make([]*lockout.Lockout, 0, 10)
+	for rows.Next() {
+		l, err := scanLockout(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan lockout")
+		}
+		lockouts = append(lockouts, l)
+	}
+
+	return lockouts, errors.Wrap(rows.Err(), "rows err")
+}
+
+// RecordFailure records a failed attempt of a client, the read and
+// the update are in a single transaction so that concurrent failed
+// attempts are all counted
+func (repo *LockoutRepository) RecordFailure(
+	ctx context.Context, client string, p lockout.Policy,
+) (l *lockout.Lockout, err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// remove the forgotten lockouts
+	now := time.Now()
+	stmnt := `delete from lockouts where failed_at < ?1
+		and (locked_until is null or locked_until < ?2)`
+	_, err = tx.ExecContext(ctx, stmnt, formatTime(now.Add(-p.ResetAfter)), formatTime(now))
+	if err != nil {
+		return nil, errors.Wrap(err, "delete forgotten lockouts")
+	}
+
+	stmnt = `insert into lockouts (client) values (?1) on conflict (client) do nothing`
+	_, err = tx.ExecContext(ctx, stmnt, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert lockout")
+	}
+
+	stmnt = `select ` + lockoutCols + ` from lockouts where client=?1`
+	l, err = scanLockout(tx.QueryRowContext(ctx, stmnt, client))
+	if err != nil {
+		return nil, errors.Wrap(err, "select lockout")
+	}
+
+	l.Fail(p, now)
+	stmnt = `update lockouts set failures=?2, locked_until=?3, failed_at=?4 where client=?1`
+	_, err = tx.ExecContext(ctx, stmnt, client, l.Failures,
+		nullTime(l.LockedUntil), nullTime(l.FailedAt))
+	if err != nil {
+		return nil, errors.Wrap(err, "update lockout")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit tx")
+	}
+
+	return l, nil
+}
+
+// DeleteLockout removes the lockout of a client from the db
+func (repo *LockoutRepository) DeleteLockout(ctx context.Context, client string) error {
+	stmnt := `delete from lockouts where client=?1`
+	res, err := repo.db.ExecContext(ctx, stmnt, client)
+	if err != nil {
+		return errors.Wrap(err, "delete lockout")
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if deleted == 0 {
+		return lockout.ErrLockoutNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/lopezator/migrator"
+)
+
+// Migrate migrates the database.
+func Migrate(db *sql.DB, opts ...migrator.Option) error {
+	opts = append(opts, migrations)
+	m, err := migrator.New(opts...)
+	if err != nil {
+		return err
+	}
+
+	return m.Migrate(db)
+}
+
+// MustMigrate migrates the database and panics if an error occurs.
+func MustMigrate(db *sql.DB, opts ...migrator.Option) {
+	err := Migrate(db, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// migrations is the list of migrations. The timestamps are set by
+// the repositories in UTC in the format of timeFormat, json values
+// and arrays are stored as text.
+var migrations = migrator.Migrations(
+	&migrator.Migration{
+		Name: "Create campaigns table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "campaigns" (
+				id text PRIMARY KEY,
+				name text NOT NULL,
+				-- default token time-to-live in nanoseconds
+				token_ttl integer NOT NULL DEFAULT 0,
+				max_redemptions integer NOT NULL DEFAULT 0,
+				starts_at timestamptz,
+				ends_at timestamptz,
+				max_tokens integer NOT NULL DEFAULT 0,
+				token_count integer NOT NULL DEFAULT 0,
+				disabled boolean NOT NULL DEFAULT FALSE,
+				created_at timestamptz NOT NULL,
+				updated_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create tokens table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "tokens" (
+				token varchar(12) PRIMARY KEY,
+				disabled boolean NOT NULL DEFAULT FALSE,
+				max_redemptions integer NOT NULL DEFAULT 1 
+					CHECK (max_redemptions > 0),
+				redemptions integer NOT NULL DEFAULT 0,
+				expires_at timestamptz,
+				not_before timestamptz,
+				revoked_at timestamptz,
+				revoke_reason text NOT NULL DEFAULT '',
+				metadata text,
+				-- json array of labels
+				labels text NOT NULL DEFAULT '[]',
+				campaign_id text REFERENCES campaigns(id),
+				redeemed_at timestamptz,
+				updated_at timestamptz,
+				created_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS tokens_campaign_id_idx 
+				ON "tokens" (campaign_id)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS tokens_created_at_idx 
+				ON "tokens" (created_at, token)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create redemptions table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "redemptions" (
+				id text PRIMARY KEY,
+				token varchar(12) NOT NULL REFERENCES tokens (token),
+				user_id text NOT NULL DEFAULT '',
+				email text NOT NULL DEFAULT '',
+				metadata text,
+				ip_address text NOT NULL DEFAULT '',
+				user_agent text NOT NULL DEFAULT '',
+				created_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS redemptions_token_idx 
+				ON "redemptions" (token)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create auth_keys table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "auth_keys" (
+				key_id varchar(8) PRIMARY KEY,
+				key_hash text NOT NULL,
+				salt text NOT NULL,
+				name text NOT NULL DEFAULT '',
+				owner text NOT NULL DEFAULT '',
+				-- json array of scopes
+				scopes text NOT NULL DEFAULT '[]',
+				-- sealed with the server-side signing secret
+				signing_key text NOT NULL DEFAULT '',
+				expires_at timestamptz,
+				revoked_at timestamptz,
+				last_used_at timestamptz,
+				created_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE TABLE IF NOT EXISTS "auth_nonces" (
+				key_id text NOT NULL REFERENCES auth_keys(key_id) ON DELETE CASCADE,
+				nonce text NOT NULL,
+				expires_at timestamptz NOT NULL,
+				PRIMARY KEY(key_id, nonce)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS auth_nonces_expires_at_idx 
+				ON "auth_nonces" (expires_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create audit_events table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "audit_events" (
+				event_id text PRIMARY KEY,
+				actor text NOT NULL DEFAULT '',
+				auth_method text NOT NULL DEFAULT '',
+				action text NOT NULL,
+				target text NOT NULL DEFAULT '',
+				status integer NOT NULL,
+				request_id text NOT NULL DEFAULT '',
+				ip_address text NOT NULL DEFAULT '',
+				created_at timestamptz NOT NULL
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `CREATE INDEX IF NOT EXISTS audit_events_created_at_idx 
+				ON "audit_events" (created_at DESC, event_id DESC)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create lockouts table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `CREATE TABLE IF NOT EXISTS "lockouts" (
+				client text PRIMARY KEY,
+				failures integer NOT NULL DEFAULT 0,
+				locked_until timestamptz,
+				failed_at timestamptz
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// the forgotten lockouts are removed on every failed attempt
+			stmnt = `CREATE INDEX IF NOT EXISTS lockouts_failed_at_idx
+				ON "lockouts" (failed_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	// Add new migration
+)
@@ -0,0 +1,19 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/sqlite"
+)
+
+func TestMigrate(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	sqlite.MustMigrate(db)
+
+	// migrating again is a no-op
+	require.NoError(t, sqlite.Migrate(db))
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// params are the connection parameters, foreign keys are not
+// enforced by sqlite unless enabled for each connection
+const params = "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+
+// Open opens a sqlite database at path, ":memory:" opens an in-memory
+// database. The pool is limited to a single connection since sqlite
+// allows only one writer at a time, this also keeps an in-memory
+// database alive for as long as the pool is open.
+func Open(path string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+sep+params)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// timeFormat is the format of the stored timestamps, timestamps are
+// stored in UTC with the zone and a fixed width so that they compare
+// as text
+const timeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// formatTime formats t as a stored timestamp
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// nullTime formats t as a stored timestamp, nil if t is nil
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return formatTime(*t)
+}
+
+// timeScanner scans a stored timestamp into a nullable time, the
+// driver only parses the columns declared as timestamp so the
+// timestamptz columns are parsed here
+type timeScanner struct {
+	t **time.Time
+}
+
+// scanTime returns a scanner of a stored timestamp into t
+func scanTime(t **time.Time) sql.Scanner {
+	return timeScanner{t: t}
+}
+
+// Scan implements sql.Scanner
+func (s timeScanner) Scan(v interface{}) error {
+	var str string
+	switch v := v.(type) {
+	case nil:
+		*s.t = nil
+		return nil
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return errors.Errorf("unexpected timestamp type %T", v)
+	}
+
+	t, err := time.Parse(timeFormat, str)
+	if err != nil {
+		return errors.Wrap(err, "parse timestamp")
+	}
+
+	*s.t = &t
+	return nil
+}
@@ -0,0 +1,11 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/invitesvc/storagetest"
+)
+
+func TestTokenRepository(t *testing.T) {
+	storagetest.TokenRepository(t, storagetest.SQLite)
+}
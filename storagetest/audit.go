@@ -0,0 +1,77 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/audit"
+)
+
+// AuditRepository tests the audit event repository of the backend
+func AuditRepository(t *testing.T, b Backend) {
+	auditRepo := b.Open(t).Audit
+
+	ctx := context.TODO()
+	event := &audit.Event{
+		ID:         audit.NewEventID(),
+		Actor:      "abcd1234",
+		AuthMethod: "authkey",
+		Action:     audit.ActionDisableToken,
+		Target:     "VxzUfkY36YQT",
+		Status:     200,
+		RequestID:  "req-1",
+		IPAddress:  "192.0.2.1",
+	}
+	err := auditRepo.CreateEvent(ctx, event)
+	require.NoError(t, err)
+	assert.NotNil(t, event.CreatedAt)
+
+	other := &audit.Event{
+		ID:     audit.NewEventID(),
+		Actor:  "efgh5678",
+		Action: audit.ActionListTokens,
+		Status: 403,
+	}
+	err = auditRepo.CreateEvent(ctx, other)
+	require.NoError(t, err)
+
+	t.Run("list events", func(t *testing.T) {
+		events, err := auditRepo.ListEvents(ctx, audit.ListQuery{})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, other.ID, events[0].ID)
+
+		gotEvent := events[1]
+		assert.Equal(t, event.ID, gotEvent.ID)
+		assert.Equal(t, event.Actor, gotEvent.Actor)
+		assert.Equal(t, event.AuthMethod, gotEvent.AuthMethod)
+		assert.Equal(t, event.Action, gotEvent.Action)
+		assert.Equal(t, event.Target, gotEvent.Target)
+		assert.Equal(t, event.Status, gotEvent.Status)
+		assert.Equal(t, event.RequestID, gotEvent.RequestID)
+		assert.Equal(t, event.IPAddress, gotEvent.IPAddress)
+	})
+
+	t.Run("list events with query", func(t *testing.T) {
+		events, err := auditRepo.ListEvents(ctx, audit.ListQuery{
+			Target: "VxzUfkY36YQT",
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, event.ID, events[0].ID)
+
+		events, err = auditRepo.ListEvents(ctx, audit.ListQuery{
+			After: &audit.Cursor{CreatedAt: *other.CreatedAt, ID: other.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, event.ID, events[0].ID)
+
+		events, err = auditRepo.ListEvents(ctx, audit.ListQuery{Limit: 1})
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
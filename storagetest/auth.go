@@ -0,0 +1,108 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/authn"
+)
+
+// AuthRepository tests the auth key repository of the backend
+func AuthRepository(t *testing.T, b Backend) {
+	authRepo := b.Open(t).Auth
+
+	ctx := context.TODO()
+	authKey := authn.NewAuthKey()
+	auth, err := authn.NewAuth(authKey)
+	require.NoError(t, err)
+	auth.Name = "ci"
+	auth.Owner = "ops@example.com"
+	auth.Scopes = []authn.Scope{authn.ScopeTokensRead, authn.ScopeKeysAdmin}
+
+	err = authRepo.CreateAuthKey(ctx, auth)
+	require.NoError(t, err)
+
+	gotAuth, err := authRepo.GetAuthKey(ctx, authKey.ID())
+	require.NoError(t, err)
+	assert.Equal(t, auth.ID, gotAuth.ID)
+	assert.NotEqual(t, string(authKey), gotAuth.Hash)
+	assert.True(t, gotAuth.Matches(authKey))
+	assert.Equal(t, "ci", gotAuth.Name)
+	assert.Equal(t, "ops@example.com", gotAuth.Owner)
+	assert.Equal(t, auth.Scopes, gotAuth.Scopes)
+	assert.NotNil(t, gotAuth.CreatedAt)
+
+	t.Run("auth key not found", func(t *testing.T) {
+		_, err := authRepo.GetAuthKey(ctx, authn.NewAuthKey().ID())
+		assert.ErrorIs(t, err, authn.ErrAuthKeyNotFound)
+	})
+
+	t.Run("duplicate auth key", func(t *testing.T) {
+		err := authRepo.CreateAuthKey(ctx, auth)
+		assert.ErrorIs(t, err, authn.ErrDuplicateAuthKey)
+	})
+
+	t.Run("create initial auth key", func(t *testing.T) {
+		auth, err := authn.NewAuth(authn.NewAuthKey())
+		require.NoError(t, err)
+
+		err = authRepo.CreateInitialAuthKey(ctx, auth)
+		assert.ErrorIs(t, err, authn.ErrAuthKeysExist)
+	})
+
+	t.Run("list auth keys", func(t *testing.T) {
+		auths, err := authRepo.ListAuthKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, auths, 1)
+		assert.Equal(t, auth.ID, auths[0].ID)
+	})
+
+	t.Run("create nonce", func(t *testing.T) {
+		nonce := &authn.Nonce{
+			KeyID:     auth.ID,
+			Value:     "0123456789abcdef",
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+		err := authRepo.CreateNonce(ctx, nonce)
+		require.NoError(t, err)
+
+		err = authRepo.CreateNonce(ctx, nonce)
+		assert.ErrorIs(t, err, authn.ErrNonceReused)
+
+		// expired nonces are removed
+		expired := &authn.Nonce{
+			KeyID:     auth.ID,
+			Value:     "fedcba9876543210",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}
+		err = authRepo.CreateNonce(ctx, expired)
+		require.NoError(t, err)
+
+		err = authRepo.CreateNonce(ctx, expired)
+		require.NoError(t, err)
+	})
+
+	t.Run("set auth key used and revoked", func(t *testing.T) {
+		err := authRepo.SetAuthKeyUsed(ctx, auth.ID)
+		require.NoError(t, err)
+
+		err = authRepo.SetAuthKeyRevoked(ctx, auth.ID)
+		require.NoError(t, err)
+
+		gotAuth, err := authRepo.GetAuthKey(ctx, auth.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, gotAuth.LastUsedAt)
+		assert.True(t, gotAuth.Revoked())
+
+		keyID := authn.NewAuthKey().ID()
+		err = authRepo.SetAuthKeyUsed(ctx, keyID)
+		assert.ErrorIs(t, err, authn.ErrAuthKeyNotFound)
+
+		err = authRepo.SetAuthKeyRevoked(ctx, keyID)
+		assert.ErrorIs(t, err, authn.ErrAuthKeyNotFound)
+	})
+}
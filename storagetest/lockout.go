@@ -0,0 +1,67 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/lockout"
+)
+
+// LockoutRepository tests the lockout repository of the backend
+func LockoutRepository(t *testing.T, b Backend) {
+	lockoutRepo := b.Open(t).Lockout
+
+	ctx := context.TODO()
+	p := lockout.Policy{
+		MaxFailures: 2,
+		Duration:    time.Minute,
+		MaxDuration: time.Hour,
+		ResetAfter:  time.Hour,
+	}
+
+	_, err := lockoutRepo.GetLockout(ctx, "192.0.2.1")
+	assert.ErrorIs(t, err, lockout.ErrLockoutNotFound)
+
+	t.Run("record failure", func(t *testing.T) {
+		l, err := lockoutRepo.RecordFailure(ctx, "192.0.2.1", p)
+		require.NoError(t, err)
+		assert.Equal(t, 1, l.Failures)
+		assert.Nil(t, l.LockedUntil)
+		assert.NotNil(t, l.FailedAt)
+
+		l, err = lockoutRepo.RecordFailure(ctx, "192.0.2.1", p)
+		require.NoError(t, err)
+		assert.Equal(t, 2, l.Failures)
+		assert.NotNil(t, l.LockedUntil)
+
+		got, err := lockoutRepo.GetLockout(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Failures)
+		require.NotNil(t, got.LockedUntil)
+		assert.WithinDuration(t, *l.LockedUntil, *got.LockedUntil, time.Second)
+
+		_, err = lockoutRepo.RecordFailure(ctx, "192.0.2.2", p)
+		require.NoError(t, err)
+	})
+
+	t.Run("list lockouts", func(t *testing.T) {
+		lockouts, err := lockoutRepo.ListLockouts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, lockouts, 2)
+	})
+
+	t.Run("delete lockout", func(t *testing.T) {
+		err := lockoutRepo.DeleteLockout(ctx, "192.0.2.1")
+		require.NoError(t, err)
+
+		_, err = lockoutRepo.GetLockout(ctx, "192.0.2.1")
+		assert.ErrorIs(t, err, lockout.ErrLockoutNotFound)
+
+		err = lockoutRepo.DeleteLockout(ctx, "192.0.2.1")
+		assert.ErrorIs(t, err, lockout.ErrLockoutNotFound)
+	})
+}
@@ -0,0 +1,47 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/ratelimit"
+)
+
+// RateLimitRepository tests the rate limit repository of the backend, the backend must
+// store rate limits
+func RateLimitRepository(t *testing.T, b Backend) {
+	rateLimitRepo := b.Open(t).RateLimit
+
+	ctx := context.TODO()
+	cfg := ratelimit.Config{Rate: 0.001, Burst: 2, ExpiresIn: time.Minute}
+
+	t.Run("take token", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			ok, err := rateLimitRepo.TakeToken(ctx, "192.0.2.1", cfg)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		ok, err := rateLimitRepo.TakeToken(ctx, "192.0.2.1", cfg)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = rateLimitRepo.TakeToken(ctx, "192.0.2.2", cfg)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("delete expired buckets", func(t *testing.T) {
+		err := rateLimitRepo.DeleteExpiredBuckets(ctx)
+		require.NoError(t, err)
+
+		// buckets that have not expired are kept
+		ok, err := rateLimitRepo.TakeToken(ctx, "192.0.2.1", cfg)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,123 @@
+// Package storagetest implements the tests shared by the storage
+// backends, the repository tests and the service tests run against
+// each backend so that all of them are covered by the same tests.
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/audit"
+	"github.com/stevenferrer/invitesvc/authn"
+	"github.com/stevenferrer/invitesvc/inmem"
+	"github.com/stevenferrer/invitesvc/lockout"
+	"github.com/stevenferrer/invitesvc/postgres"
+	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/ratelimit"
+	"github.com/stevenferrer/invitesvc/sqlite"
+	"github.com/stevenferrer/invitesvc/token"
+)
+
+// Repositories are the repositories of a storage backend
+type Repositories struct {
+	Token   token.Repository
+	Auth    authn.Repository
+	Audit   audit.Repository
+	Lockout lockout.Repository
+	// RateLimit is nil if the backend doesn't store rate limits
+	RateLimit ratelimit.Repository
+}
+
+// NewRateLimiterStore returns a rate limiter store backed by the rate limit
+// repository, or a memory store if the backend doesn't store rate limits
+func (r Repositories) NewRateLimiterStore(name string, cfg ratelimit.Config) middleware.RateLimiterStore {
+	if r.RateLimit == nil {
+		return ratelimit.NewMemoryStore(cfg)
+	}
+
+	return ratelimit.NewStore(r.RateLimit, name, cfg)
+}
+
+// Backend is a storage backend
+type Backend struct {
+	Name string
+	// Open opens empty repositories, the database is closed
+	// when the test and its subtests complete
+	Open func(t *testing.T) Repositories
+}
+
+// Inmem is the in-memory backend
+var Inmem = Backend{
+	Name: "inmem",
+	Open: func(t *testing.T) Repositories {
+		db, err := memdb.NewMemDB(inmem.Schema())
+		require.NoError(t, err)
+
+		return Repositories{
+			Token:     inmem.NewTokenRepository(db),
+			Auth:      inmem.NewAuthRepository(db),
+			Audit:     inmem.NewAuditRepository(db),
+			Lockout:   inmem.NewLockoutRepository(db),
+			RateLimit: inmem.NewRateLimitRepository(db),
+		}
+	},
+}
+
+// Postgres is the postgres backend, the changes are rolled back
+// when the database is closed
+var Postgres = Backend{
+	Name: "postgres",
+	Open: func(t *testing.T) Repositories {
+		db := txdb.MustOpen()
+		t.Cleanup(func() { db.Close() })
+
+		// migrate db
+		postgres.MustMigrate(db)
+
+		return Repositories{
+			Token:     postgres.NewTokenRepository(db),
+			Auth:      postgres.NewAuthRepository(db),
+			Audit:     postgres.NewAuditRepository(db),
+			Lockout:   postgres.NewLockoutRepository(db),
+			RateLimit: postgres.NewRateLimitRepository(db),
+		}
+	},
+}
+
+// SQLite is the sqlite backend using an in-memory database
+var SQLite = Backend{
+	Name: "sqlite",
+	Open: func(t *testing.T) Repositories {
+		db, err := sqlite.Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		// migrate db
+		sqlite.MustMigrate(db)
+
+		return Repositories{
+			Token:   sqlite.NewTokenRepository(db),
+			Auth:    sqlite.NewAuthRepository(db),
+			Audit:   sqlite.NewAuditRepository(db),
+			Lockout: sqlite.NewLockoutRepository(db),
+		}
+	},
+}
+
+// Backends returns all the storage backends
+func Backends() []Backend {
+	return []Backend{Inmem, SQLite, Postgres}
+}
+
+// Run runs f as a subtest for each of the storage backends
+func Run(t *testing.T, f func(t *testing.T, b Backend)) {
+	for _, b := range Backends() {
+		b := b
+		t.Run(b.Name, func(t *testing.T) {
+			f(t, b)
+		})
+	}
+}
@@ -0,0 +1,659 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/invitesvc/token"
+)
+
+// TokenRepository tests the token repository of the backend
+func TokenRepository(t *testing.T, b Backend) {
+	ctx := context.TODO()
+	tokenRepo := b.Open(t).Token
+
+	t.Run("create and retrieve token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		expiresAt := time.Now().Add(token.DefaultTTL)
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID,
+			MaxRedemptions: 1, ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+
+		gotToken, err := tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+
+		assert.Equal(t, tokenID, gotToken.ID)
+		assert.NotNil(t, gotToken.CreatedAt)
+		require.NotNil(t, gotToken.ExpiresAt)
+		assert.WithinDuration(t, expiresAt, *gotToken.ExpiresAt, time.Second)
+		assert.Nil(t, gotToken.RedeemedAt)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err = token.NewID()
+			require.NoError(t, err)
+
+			gotToken, err = tokenRepo.GetToken(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+			assert.Nil(t, gotToken)
+		})
+	})
+
+	t.Run("list tokens", func(t *testing.T) {
+		gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 1)
+
+	})
+
+	t.Run("set token to disabled", func(t *testing.T) {
+		gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 1)
+
+		tk := gotTokens[0]
+
+		// set to disabled
+		err = tokenRepo.SetTokenDisabled(ctx, tk.ID)
+		require.NoError(t, err)
+
+		// verify update
+		gotTk, err := tokenRepo.GetToken(ctx, tk.ID)
+		require.NoError(t, err)
+		assert.True(t, gotTk.Disabled)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenRepo.SetTokenDisabled(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("redeem token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: 1})
+		require.NoError(t, err)
+
+		// redeem token
+		r := newRedemption(tokenID)
+		r.UserID = "user-1"
+		r.Email = "user@example.com"
+		r.Metadata = map[string]string{"source": "meetup"}
+		err = tokenRepo.RedeemToken(ctx, r)
+		require.NoError(t, err)
+
+		// verify update
+		gotTk, err := tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.NotNil(t, gotTk.RedeemedAt)
+		assert.False(t, gotTk.Disabled)
+
+		// verify redemption record
+		redemptions, err := tokenRepo.ListRedemptions(ctx, tokenID)
+		require.NoError(t, err)
+		require.Len(t, redemptions, 1)
+		assert.Equal(t, r.ID, redemptions[0].ID)
+		assert.Equal(t, tokenID, redemptions[0].Token)
+		assert.Equal(t, "user-1", redemptions[0].UserID)
+		assert.Equal(t, "user@example.com", redemptions[0].Email)
+		assert.Equal(t, "127.0.0.1", redemptions[0].IPAddress)
+		assert.Equal(t, "meetup", redemptions[0].Metadata["source"])
+		assert.NotNil(t, redemptions[0].CreatedAt)
+
+		t.Run("token redeemed", func(t *testing.T) {
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenRedeemed)
+
+			// failed redemptions are not recorded
+			redemptions, err := tokenRepo.ListRedemptions(ctx, tokenID)
+			require.NoError(t, err)
+			assert.Len(t, redemptions, 1)
+		})
+
+		t.Run("token disabled", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: 1})
+			require.NoError(t, err)
+
+			err = tokenRepo.SetTokenDisabled(ctx, tokenID)
+			require.NoError(t, err)
+
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenDisabled)
+		})
+
+		t.Run("token expired", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			expiresAt := time.Now().Add(-time.Hour)
+			err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID,
+				MaxRedemptions: 1, ExpiresAt: &expiresAt})
+			require.NoError(t, err)
+
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenExpired)
+		})
+
+		t.Run("token not yet valid", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			notBefore := time.Now().Add(time.Hour)
+			err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID,
+				MaxRedemptions: 1, NotBefore: &notBefore})
+			require.NoError(t, err)
+
+			gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+				Status: token.StatusPending,
+			})
+			require.NoError(t, err)
+			require.Len(t, gotTokens, 1)
+			assert.Equal(t, tokenID, gotTokens[0].ID)
+
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenNotYetValid)
+		})
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("redeem multi-use token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: 3})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			require.NoError(t, err)
+		}
+
+		// verify update
+		gotTk, err := tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, gotTk.MaxRedemptions)
+		assert.Equal(t, 3, gotTk.Redemptions)
+		assert.Equal(t, 0, gotTk.Remaining())
+
+		t.Run("token exhausted", func(t *testing.T) {
+			err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+			assert.ErrorIs(t, err, token.ErrTokenExhausted)
+		})
+	})
+
+	t.Run("enable, extend and revoke token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		expiresAt := time.Now().Add(time.Hour)
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID,
+			MaxRedemptions: 1, ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+
+		// disable and enable
+		err = tokenRepo.SetTokenDisabled(ctx, tokenID)
+		require.NoError(t, err)
+
+		err = tokenRepo.SetTokenEnabled(ctx, tokenID)
+		require.NoError(t, err)
+
+		gotTk, err := tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.False(t, gotTk.Disabled)
+
+		// extend expiration
+		expiresAt = time.Now().Add(48 * time.Hour)
+		err = tokenRepo.SetTokenExpiration(ctx, tokenID, &expiresAt)
+		require.NoError(t, err)
+
+		gotTk, err = tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, expiresAt, *gotTk.ExpiresAt, time.Second)
+
+		// never expires
+		err = tokenRepo.SetTokenExpiration(ctx, tokenID, nil)
+		require.NoError(t, err)
+
+		gotTk, err = tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Nil(t, gotTk.ExpiresAt)
+
+		// revoke
+		err = tokenRepo.SetTokenRevoked(ctx, tokenID, "leaked")
+		require.NoError(t, err)
+
+		gotTk, err = tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.True(t, gotTk.Revoked())
+		assert.Equal(t, "leaked", gotTk.RevokeReason)
+		assert.Equal(t, token.StatusRevoked, gotTk.Status())
+
+		err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+		assert.ErrorIs(t, err, token.ErrTokenRevoked)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenRepo.SetTokenEnabled(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+
+			err = tokenRepo.SetTokenExpiration(ctx, tokenID, nil)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+
+			err = tokenRepo.SetTokenRevoked(ctx, tokenID, "leaked")
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("create tokens", func(t *testing.T) {
+		const n = 2500
+		tokens := make([]*token.Token, 0, n)
+		for i := 0; i < n; i++ {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			tokens = append(tokens, &token.Token{ID: tokenID, MaxRedemptions: 1})
+		}
+
+		err := tokenRepo.CreateTokens(ctx, tokens)
+		require.NoError(t, err)
+
+		for _, tk := range []*token.Token{tokens[0], tokens[n-1]} {
+			gotTk, err := tokenRepo.GetToken(ctx, tk.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tk.ID, gotTk.ID)
+		}
+
+		t.Run("duplicate token", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenRepo.CreateTokens(ctx, []*token.Token{
+				{ID: tokenID, MaxRedemptions: 1},
+				{ID: tokens[0].ID, MaxRedemptions: 1},
+			})
+			assert.ErrorIs(t, err, token.ErrDuplicateToken)
+
+			// nothing must be created
+			_, err = tokenRepo.GetToken(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+
+			err = tokenRepo.CreateToken(ctx, tokens[0])
+			assert.ErrorIs(t, err, token.ErrDuplicateToken)
+		})
+	})
+
+	t.Run("list tokens with query", func(t *testing.T) {
+		gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 10)
+
+		// next page
+		last := gotTokens[len(gotTokens)-1]
+		nextTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+			Limit: 10,
+			After: &token.Cursor{CreatedAt: *last.CreatedAt, ID: last.ID},
+		})
+		require.NoError(t, err)
+		assert.Len(t, nextTokens, 10)
+		assert.False(t, nextTokens[0].CreatedAt.Before(*last.CreatedAt))
+		assert.NotEqual(t, last.ID, nextTokens[0].ID)
+
+		// sort descending
+		descTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+			Limit: 10,
+			Sort:  token.SortDesc,
+		})
+		require.NoError(t, err)
+		assert.Len(t, descTokens, 10)
+		assert.False(t, descTokens[0].CreatedAt.Before(*descTokens[9].CreatedAt))
+
+		// a token that was redeemed and then expired stays redeemed
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: 1})
+		require.NoError(t, err)
+
+		err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+		require.NoError(t, err)
+
+		expiredAt := time.Now().Add(-time.Hour)
+		err = tokenRepo.SetTokenExpiration(ctx, tokenID, &expiredAt)
+		require.NoError(t, err)
+
+		err = tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+		assert.ErrorIs(t, err, token.ErrTokenRedeemed)
+
+		// filter by status
+		for _, status := range []token.Status{token.StatusDisabled,
+			token.StatusRedeemed, token.StatusExpired, token.StatusRevoked} {
+			gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{Status: status})
+			require.NoError(t, err)
+			assert.NotEmpty(t, gotTokens)
+
+			var found bool
+			for _, tk := range gotTokens {
+				assert.Equal(t, status, tk.Status())
+				found = found || tk.ID == tokenID
+			}
+			assert.Equal(t, status == token.StatusRedeemed, found, status)
+		}
+
+		// filter by created at
+		future := time.Now().Add(time.Hour)
+		gotTokens, err = tokenRepo.ListTokens(ctx, token.ListQuery{CreatedAfter: &future})
+		require.NoError(t, err)
+		assert.Empty(t, gotTokens)
+
+		gotTokens, err = tokenRepo.ListTokens(ctx, token.ListQuery{CreatedBefore: &future, Limit: 5})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 5)
+	})
+
+	t.Run("metadata and labels", func(t *testing.T) {
+		var tks []*token.Token
+		for _, labels := range [][]string{{"launch", "meetup"}, {"launch"}, nil} {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			tks = append(tks, &token.Token{
+				ID:             tokenID,
+				MaxRedemptions: 1,
+				Metadata: map[string]interface{}{
+					"recipient": "alice@example.com",
+					"wave":      float64(2),
+				},
+				Labels: labels,
+			})
+		}
+
+		err := tokenRepo.CreateTokens(ctx, tks)
+		require.NoError(t, err)
+
+		gotToken, err := tokenRepo.GetToken(ctx, tks[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, tks[0].Metadata, gotToken.Metadata)
+		assert.Equal(t, []string{"launch", "meetup"}, gotToken.Labels)
+
+		// filter by labels
+		gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+			Labels: []string{"launch"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 2)
+
+		gotTokens, err = tokenRepo.ListTokens(ctx, token.ListQuery{
+			Labels: []string{"launch", "meetup"},
+		})
+		require.NoError(t, err)
+		require.Len(t, gotTokens, 1)
+		assert.Equal(t, tks[0].ID, gotTokens[0].ID)
+
+		gotTokens, err = tokenRepo.ListTokens(ctx, token.ListQuery{
+			Labels: []string{"unknown"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, gotTokens)
+	})
+
+	t.Run("campaigns", func(t *testing.T) {
+		campaign := &token.Campaign{
+			ID:             token.NewCampaignID(),
+			Name:           "Meetup",
+			TTL:            time.Hour,
+			MaxRedemptions: 2,
+			MaxTokens:      3,
+		}
+		err := tokenRepo.CreateCampaign(ctx, campaign)
+		require.NoError(t, err)
+		assert.NotNil(t, campaign.CreatedAt)
+
+		gotCampaign, err := tokenRepo.GetCampaign(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Meetup", gotCampaign.Name)
+		assert.Equal(t, time.Hour, gotCampaign.TTL)
+		assert.Equal(t, 2, gotCampaign.MaxRedemptions)
+		assert.Equal(t, 3, gotCampaign.MaxTokens)
+		assert.Zero(t, gotCampaign.Tokens)
+
+		var tks []*token.Token
+		for i := 0; i < 2; i++ {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			tks = append(tks, &token.Token{ID: tokenID,
+				MaxRedemptions: 1, CampaignID: campaign.ID})
+		}
+
+		err = tokenRepo.CreateTokens(ctx, tks)
+		require.NoError(t, err)
+
+		gotToken, err := tokenRepo.GetToken(ctx, tks[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, campaign.ID, gotToken.CampaignID)
+		assert.False(t, gotToken.CampaignDisabled)
+
+		gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+			Campaign: campaign.ID,
+		})
+		require.NoError(t, err)
+		assert.Len(t, gotTokens, 2)
+
+		gotCampaign, err = tokenRepo.GetCampaign(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, gotCampaign.Tokens)
+
+		t.Run("campaign full", func(t *testing.T) {
+			var tks []*token.Token
+			for i := 0; i < 2; i++ {
+				tokenID, err := token.NewID()
+				require.NoError(t, err)
+
+				tks = append(tks, &token.Token{ID: tokenID,
+					MaxRedemptions: 1, CampaignID: campaign.ID})
+			}
+
+			err := tokenRepo.CreateTokens(ctx, tks)
+			assert.ErrorIs(t, err, token.ErrCampaignFull)
+
+			// nothing was created
+			_, err = tokenRepo.GetToken(ctx, tks[0].ID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+
+		t.Run("campaign disabled", func(t *testing.T) {
+			err := tokenRepo.SetCampaignDisabled(ctx, campaign.ID, true)
+			require.NoError(t, err)
+
+			gotToken, err := tokenRepo.GetToken(ctx, tks[0].ID)
+			require.NoError(t, err)
+			assert.True(t, gotToken.CampaignDisabled)
+
+			gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{
+				Campaign: campaign.ID,
+				Status:   token.StatusDisabled,
+			})
+			require.NoError(t, err)
+			assert.Len(t, gotTokens, 2)
+
+			err = tokenRepo.RedeemToken(ctx, &token.Redemption{
+				ID:    token.NewRedemptionID(),
+				Token: tks[0].ID,
+			})
+			assert.ErrorIs(t, err, token.ErrCampaignDisabled)
+
+			err = tokenRepo.SetCampaignDisabled(ctx, campaign.ID, false)
+			require.NoError(t, err)
+
+			err = tokenRepo.RedeemToken(ctx, &token.Redemption{
+				ID:    token.NewRedemptionID(),
+				Token: tks[0].ID,
+			})
+			assert.NoError(t, err)
+		})
+
+		t.Run("update campaign", func(t *testing.T) {
+			endsAt := time.Now().Add(time.Hour)
+			campaign.Name = "Meetup 2021"
+			campaign.EndsAt = &endsAt
+			campaign.MaxTokens = 0
+			err := tokenRepo.UpdateCampaign(ctx, campaign)
+			require.NoError(t, err)
+
+			gotCampaign, err := tokenRepo.GetCampaign(ctx, campaign.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Meetup 2021", gotCampaign.Name)
+			require.NotNil(t, gotCampaign.EndsAt)
+			assert.WithinDuration(t, endsAt, *gotCampaign.EndsAt, time.Second)
+			assert.Zero(t, gotCampaign.MaxTokens)
+			assert.Equal(t, 2, gotCampaign.Tokens)
+		})
+
+		t.Run("list and delete campaigns", func(t *testing.T) {
+			empty := &token.Campaign{ID: token.NewCampaignID(), Name: "Empty"}
+			err := tokenRepo.CreateCampaign(ctx, empty)
+			require.NoError(t, err)
+
+			campaigns, err := tokenRepo.ListCampaigns(ctx)
+			require.NoError(t, err)
+			require.Len(t, campaigns, 2)
+			assert.ElementsMatch(t, []token.CampaignID{campaign.ID, empty.ID},
+				[]token.CampaignID{campaigns[0].ID, campaigns[1].ID})
+
+			err = tokenRepo.DeleteCampaign(ctx, campaign.ID)
+			assert.ErrorIs(t, err, token.ErrCampaignHasTokens)
+
+			err = tokenRepo.DeleteCampaign(ctx, empty.ID)
+			require.NoError(t, err)
+
+			_, err = tokenRepo.GetCampaign(ctx, empty.ID)
+			assert.ErrorIs(t, err, token.ErrCampaignNotFound)
+
+			err = tokenRepo.DeleteCampaign(ctx, empty.ID)
+			assert.ErrorIs(t, err, token.ErrCampaignNotFound)
+		})
+	})
+
+	t.Run("count tokens", func(t *testing.T) {
+		counts, err := tokenRepo.CountTokens(ctx)
+		require.NoError(t, err)
+
+		for _, status := range token.Statuses {
+			gotTokens, err := tokenRepo.ListTokens(ctx, token.ListQuery{Status: status})
+			require.NoError(t, err)
+			assert.Equal(t, len(gotTokens), counts[status], status)
+		}
+	})
+
+	t.Run("concurrent redeem token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: 1})
+		require.NoError(t, err)
+
+		const n = 50
+		var (
+			wg       sync.WaitGroup
+			redeemed int32
+			failed   int32
+		)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+				if err == nil {
+					atomic.AddInt32(&redeemed, 1)
+					return
+				}
+
+				if errors.Is(err, token.ErrTokenRedeemed) {
+					atomic.AddInt32(&failed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// only one redemption must succeed
+		assert.Equal(t, int32(1), redeemed)
+		assert.Equal(t, int32(n-1), failed)
+	})
+
+	t.Run("concurrent redeem multi-use token", func(t *testing.T) {
+		tokenID, err := token.NewID()
+		require.NoError(t, err)
+
+		const n, maxRedemptions = 50, 5
+		err = tokenRepo.CreateToken(ctx, &token.Token{ID: tokenID, MaxRedemptions: maxRedemptions})
+		require.NoError(t, err)
+
+		var (
+			wg       sync.WaitGroup
+			redeemed int32
+			failed   int32
+		)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := tokenRepo.RedeemToken(ctx, newRedemption(tokenID))
+				if err == nil {
+					atomic.AddInt32(&redeemed, 1)
+					return
+				}
+
+				if errors.Is(err, token.ErrTokenExhausted) {
+					atomic.AddInt32(&failed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// redemptions must not exceed the limit
+		assert.Equal(t, int32(maxRedemptions), redeemed)
+		assert.Equal(t, int32(n-maxRedemptions), failed)
+
+		gotTk, err := tokenRepo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Equal(t, maxRedemptions, gotTk.Redemptions)
+
+		redemptions, err := tokenRepo.ListRedemptions(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Len(t, redemptions, maxRedemptions)
+	})
+}
+
+func newRedemption(tokenID token.ID) *token.Redemption {
+	return &token.Redemption{
+		ID:        token.NewRedemptionID(),
+		Token:     tokenID,
+		IPAddress: "127.0.0.1",
+		UserAgent: "test",
+	}
+}
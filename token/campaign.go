@@ -0,0 +1,106 @@
+package token
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCampaignNameLen is the max length of a campaign name
+const maxCampaignNameLen = 128
+
+// CampaignID is a campaign id
+type CampaignID string
+
+// NilCampaignID is a nil campaign id
+var NilCampaignID = CampaignID("")
+
+// NewCampaignID returns a new campaign id
+func NewCampaignID() CampaignID {
+	return CampaignID(uuid.NewString())
+}
+
+// Campaign is a group of invite tokens sharing the same settings
+type Campaign struct {
+	// ID is the campaign id
+	ID CampaignID `json:"id"`
+	// Name is the campaign name
+	Name string `json:"name"`
+	// TTL is the default time-to-live of the campaign
+	// tokens, zero means the service default is used
+	TTL time.Duration `json:"ttl"`
+	// MaxRedemptions is the default number of times
+	// a campaign token can be redeemed, zero means single-use
+	MaxRedemptions int `json:"maxRedemptions"`
+	// StartsAt is the start of the active window, nil if active on creation
+	StartsAt *time.Time `json:"startsAt"`
+	// EndsAt is the end of the active window, nil if never ends
+	EndsAt *time.Time `json:"endsAt"`
+	// MaxTokens is the max number of tokens generated
+	// under the campaign, zero means no limit
+	MaxTokens int `json:"maxTokens"`
+	// Tokens is the number of tokens generated under the campaign
+	Tokens int `json:"tokens"`
+	// Disabled is true when the campaign is disabled, tokens
+	// of a disabled campaign cannot be redeemed
+	Disabled bool `json:"disabled"`
+	// CreatedAt is the created at timestamp
+	CreatedAt *time.Time `json:"createdAt"`
+	// UpdatedAt is the updated at timestamp
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+// Started returns true if the active window has started
+func (c *Campaign) Started() bool {
+	return c.StartsAt == nil || !time.Now().Before(*c.StartsAt)
+}
+
+// Ended returns true if the active window has ended
+func (c *Campaign) Ended() bool {
+	return c.EndsAt != nil && !time.Now().Before(*c.EndsAt)
+}
+
+// Active returns true if the campaign tokens can be redeemed
+func (c *Campaign) Active() bool {
+	return !c.Disabled && c.Started() && !c.Ended()
+}
+
+// Full returns true if the campaign token cap is reached
+func (c *Campaign) Full() bool {
+	return c.MaxTokens > 0 && c.Tokens >= c.MaxTokens
+}
+
+// CampaignParams is the campaign create and update parameters
+type CampaignParams struct {
+	// Name is the campaign name
+	Name string
+	// TTL is the default time-to-live of the campaign tokens
+	TTL time.Duration
+	// MaxRedemptions is the default redemption limit of the campaign tokens
+	MaxRedemptions int
+	// StartsAt is the start of the active window
+	StartsAt *time.Time
+	// EndsAt is the end of the active window
+	EndsAt *time.Time
+	// MaxTokens is the max number of tokens generated under the campaign
+	MaxTokens int
+}
+
+// validate validates the campaign params
+func (p *CampaignParams) validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len(p.Name) > maxCampaignNameLen {
+		return ErrInvalidCampaign
+	}
+
+	if p.TTL < 0 || p.MaxRedemptions < 0 || p.MaxTokens < 0 {
+		return ErrInvalidCampaign
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return ErrInvalidCampaign
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package token
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/invitesvc/audit"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// campaignRequest is the request for creating and updating campaign
+type campaignRequest struct {
+	Name           string     `json:"name"`
+	TTL            string     `json:"ttl"`
+	MaxRedemptions int        `json:"maxRedemptions"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxTokens      int        `json:"maxTokens"`
+}
+
+// params returns the campaign params from the request
+func (req campaignRequest) params() (CampaignParams, error) {
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			return CampaignParams{}, errors.New("invalid ttl")
+		}
+	}
+
+	return CampaignParams{
+		Name:           req.Name,
+		TTL:            ttl,
+		MaxRedemptions: req.MaxRedemptions,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		MaxTokens:      req.MaxTokens,
+	}, nil
+}
+
+// campaignResponse is a campaign response
+type campaignResponse struct {
+	ID             CampaignID `json:"id"`
+	Name           string     `json:"name"`
+	TTL            string     `json:"ttl,omitempty"`
+	MaxRedemptions int        `json:"maxRedemptions"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxTokens      int        `json:"maxTokens"`
+	Tokens         int        `json:"tokens"`
+	Disabled       bool       `json:"disabled"`
+	Active         bool       `json:"active"`
+	CreatedAt      *time.Time `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt"`
+}
+
+// newCampaignResponse returns a campaign response from a campaign
+func newCampaignResponse(c *Campaign) campaignResponse {
+	resp := campaignResponse{
+		ID:             c.ID,
+		Name:           c.Name,
+		MaxRedemptions: c.MaxRedemptions,
+		StartsAt:       c.StartsAt,
+		EndsAt:         c.EndsAt,
+		MaxTokens:      c.MaxTokens,
+		Tokens:         c.Tokens,
+		Disabled:       c.Disabled,
+		Active:         c.Active(),
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+	if c.TTL > 0 {
+		resp.TTL = c.TTL.String()
+	}
+
+	return resp
+}
+
+// createCampaign handles create campaign request
+func (h *adminHandler) createCampaign(c echo.Context) error {
+	var req campaignRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	params, err := req.params()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	campaign, err := h.tokenSvc.CreateCampaign(c.Request().Context(), params)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCampaign) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		return errors.Wrap(err, "create campaign")
+	}
+
+	audit.SetTarget(c, string(campaign.ID))
+	return c.JSON(http.StatusCreated, newCampaignResponse(campaign))
+}
+
+// getCampaign handles get campaign request
+func (h *adminHandler) getCampaign(c echo.Context) error {
+	campaignID := CampaignID(c.Param("campaign"))
+	campaign, err := h.tokenSvc.GetCampaign(c.Request().Context(), campaignID)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "campaign not found")
+		}
+
+		return errors.Wrap(err, "get campaign")
+	}
+
+	return c.JSON(http.StatusOK, newCampaignResponse(campaign))
+}
+
+// listCampaignsResponse is the list campaigns response
+type listCampaignsResponse struct {
+	Campaigns []campaignResponse `json:"campaigns"`
+}
+
+// listCampaigns handles list campaigns request
+func (h *adminHandler) listCampaigns(c echo.Context) error {
+	campaigns, err := h.tokenSvc.ListCampaigns(c.Request().Context())
+	if err != nil {
+		return errors.Wrap(err, "list campaigns")
+	}
+
+	resp := listCampaignsResponse{
+		Campaigns: make([]campaignResponse, 0, len(campaigns)),
+	}
+	for _, campaign := range campaigns {
+		resp.Campaigns = append(resp.Campaigns, newCampaignResponse(campaign))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateCampaign handles update campaign request
+func (h *adminHandler) updateCampaign(c echo.Context) error {
+	var req campaignRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	params, err := req.params()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	campaignID := CampaignID(c.Param("campaign"))
+	campaign, err := h.tokenSvc.UpdateCampaign(c.Request().Context(), campaignID, params)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "campaign not found")
+		}
+
+		if errors.Is(err, ErrInvalidCampaign) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		return errors.Wrap(err, "update campaign")
+	}
+
+	return c.JSON(http.StatusOK, newCampaignResponse(campaign))
+}
+
+// disableCampaign handles disable campaign request
+func (h *adminHandler) disableCampaign(c echo.Context) error {
+	campaignID := CampaignID(c.Param("campaign"))
+	err := h.tokenSvc.DisableCampaign(c.Request().Context(), campaignID)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "campaign not found")
+		}
+
+		return errors.Wrap(err, "disable campaign")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "campaign successfully disabled.",
+	})
+}
+
+// enableCampaign handles enable campaign request
+func (h *adminHandler) enableCampaign(c echo.Context) error {
+	campaignID := CampaignID(c.Param("campaign"))
+	err := h.tokenSvc.EnableCampaign(c.Request().Context(), campaignID)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "campaign not found")
+		}
+
+		return errors.Wrap(err, "enable campaign")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "campaign successfully enabled.",
+	})
+}
+
+// deleteCampaign handles delete campaign request
+func (h *adminHandler) deleteCampaign(c echo.Context) error {
+	campaignID := CampaignID(c.Param("campaign"))
+	err := h.tokenSvc.DeleteCampaign(c.Request().Context(), campaignID)
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "campaign not found")
+		}
+
+		if errors.Is(err, ErrCampaignHasTokens) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		return errors.Wrap(err, "delete campaign")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "campaign successfully deleted.",
+	})
+}
+
+// isCampaignConflictErr returns true if err is caused by generating
+// tokens under a campaign that does not accept new tokens
+func isCampaignConflictErr(err error) bool {
+	return errors.Is(err, ErrCampaignDisabled) ||
+		errors.Is(err, ErrCampaignEnded) ||
+		errors.Is(err, ErrCampaignFull)
+}
@@ -6,8 +6,31 @@ import (
 
 // List of token related errors
 var (
-	ErrTokenNotFound = errors.New("token not found")
-	ErrTokenDisabled = errors.New("token is disabled")
-	ErrTokenExpired  = errors.New("token already expired")
-	ErrTokenRedeemed = errors.New("token already redeemed")
+	ErrTokenNotFound    = errors.New("token not found")
+	ErrTokenDisabled    = errors.New("token is disabled")
+	ErrTokenExpired     = errors.New("token already expired")
+	ErrTokenRedeemed    = errors.New("token already redeemed")
+	ErrTokenExhausted   = errors.New("token has no remaining redemptions")
+	ErrTokenRevoked     = errors.New("token is revoked")
+	ErrTokenEnabled     = errors.New("token is not disabled")
+	ErrTokenNotYetValid = errors.New("token cannot be redeemed yet")
+
+	ErrInvalidMaxRedemptions = errors.New("max redemptions must be at least 1")
+	ErrInvalidExpiration     = errors.New("invalid token expiration")
+	ErrInvalidNotBefore      = errors.New("invalid token not before")
+	ErrInvalidCount          = errors.New("invalid token count")
+	ErrDuplicateToken        = errors.New("token already exists")
+	ErrInvalidListQuery      = errors.New("invalid list query")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrInvalidRevokeReason   = errors.New("revoke reason is required")
+	ErrInvalidLabels         = errors.New("invalid token labels")
+	ErrInvalidMetadata       = errors.New("invalid token metadata")
+	ErrInvalidRedemption     = errors.New("invalid redemption")
+
+	ErrCampaignNotFound  = errors.New("campaign not found")
+	ErrCampaignDisabled  = errors.New("campaign is disabled")
+	ErrCampaignEnded     = errors.New("campaign already ended")
+	ErrCampaignFull      = errors.New("campaign token limit reached")
+	ErrCampaignHasTokens = errors.New("campaign has tokens")
+	ErrInvalidCampaign   = errors.New("invalid campaign")
 )
@@ -1,37 +1,136 @@
 package token
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/stevenferrer/invitesvc/audit"
 	"github.com/stevenferrer/invitesvc/authn"
+	"github.com/stevenferrer/invitesvc/lockout"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/pkg/errors"
 )
 
-// InitAdminRoutes initializes admin routes
-func InitAdminRoutes(e *echo.Echo, tokenSvc Service, authSvc authn.Service) {
+// AdminRateLimiters are the rate limiter stores of the admin routes
+type AdminRateLimiters struct {
+	// Client is the rate limiter store of the requests per client ip,
+	// it is used before authentication so that requests with bad or
+	// missing credentials are throttled as well
+	Client middleware.RateLimiterStore
+	// Principal is the rate limiter store of the authenticated
+	// requests per caller
+	Principal middleware.RateLimiterStore
+}
+
+// InitAdminRoutes initializes admin routes, auth keys and signed
+// requests are always accepted alongside the additional authenticators.
+// The requests are throttled per client ip before authentication and per
+// authenticated caller after, using the buckets in the limiter stores.
+// All admin actions are recorded in the audit log.
+func InitAdminRoutes(
+	e *echo.Echo,
+	tokenSvc Service,
+	authSvc authn.Service,
+	auditSvc audit.Service,
+	lockoutSvc lockout.Service,
+	limiters AdminRateLimiters,
+	authenticators ...authn.Authenticator,
+) {
 	g := e.Group("/admin")
+	// throttle the guessing of credentials
+	g.Use(newRateLimitMiddleware(limiters.Client, ipIdentifier))
 	// use auth middleware
-	g.Use(authn.NewAuthMiddleware(authSvc))
-	// include auth key handler for generating new authkeys
-	g.POST("/authkey", authn.NewAuthKeyHandler(authSvc))
+	authenticators = append([]authn.Authenticator{
+		authn.NewAuthKeyAuthenticator(authSvc),
+		authn.NewSignatureAuthenticator(authSvc),
+	}, authenticators...)
+	g.Use(authn.NewMiddleware(authenticators...))
+	// the caller is known only after authentication
+	g.Use(newRateLimitMiddleware(limiters.Principal, principalIdentifier))
+
+	// audited is used before the scope middleware so
+	// that forbidden attempts are recorded as well
+	audited := func(action audit.Action) echo.MiddlewareFunc {
+		return audit.NewMiddleware(auditSvc, action)
+	}
+
+	// include auth key handlers for managing authkeys
+	keysAdmin := authn.NewScopeMiddleware(authn.ScopeKeysAdmin)
+	g.POST("/authkey", authn.NewAuthKeyHandler(authSvc),
+		audited(audit.ActionGenerateAuthKey), keysAdmin)
+	g.GET("/authkeys", authn.NewListAuthKeysHandler(authSvc),
+		audited(audit.ActionListAuthKeys), keysAdmin)
+	g.PUT("/authkeys/:keyId/revoke", authn.NewRevokeAuthKeyHandler(authSvc),
+		audited(audit.ActionRevokeAuthKey), keysAdmin)
+
+	// include audit handlers
+	auditRead := authn.NewScopeMiddleware(authn.ScopeAuditRead)
+	g.GET("/audit", audit.NewListEventsHandler(auditSvc),
+		audited(audit.ActionListEvents), auditRead)
 
 	h := &adminHandler{tokenSvc: tokenSvc}
 
-	g.POST("/tokens", h.generateTokens)
-	g.GET("/tokens", h.listTokens)
-	g.GET("/tokens/:token", h.getToken)
-	g.PUT("/tokens/:token/disable", h.disableToken)
+	tokensRead := authn.NewScopeMiddleware(authn.ScopeTokensRead)
+	g.GET("/tokens", h.listTokens, audited(audit.ActionListTokens), tokensRead)
+	g.GET("/tokens/summary", h.summarizeTokens, audited(audit.ActionSummarizeTokens), tokensRead)
+	g.GET("/tokens/:token", h.getToken, audited(audit.ActionGetToken), tokensRead)
+
+	tokensWrite := authn.NewScopeMiddleware(authn.ScopeTokensWrite)
+	g.POST("/tokens", h.generateTokens, audited(audit.ActionGenerateTokens), tokensWrite)
+	g.PUT("/tokens/:token/disable", h.disableToken, audited(audit.ActionDisableToken), tokensWrite)
+	g.PUT("/tokens/:token/enable", h.enableToken, audited(audit.ActionEnableToken), tokensWrite)
+	g.PUT("/tokens/:token/extend", h.extendToken, audited(audit.ActionExtendToken), tokensWrite)
+	g.PUT("/tokens/:token/revoke", h.revokeToken, audited(audit.ActionRevokeToken), tokensWrite)
+
+	// campaigns are managed with the same scopes as tokens
+	g.GET("/campaigns", h.listCampaigns, audited(audit.ActionListCampaigns), tokensRead)
+	g.GET("/campaigns/:campaign", h.getCampaign, audited(audit.ActionGetCampaign), tokensRead)
+	g.POST("/campaigns", h.createCampaign, audited(audit.ActionCreateCampaign), tokensWrite)
+	g.PUT("/campaigns/:campaign", h.updateCampaign, audited(audit.ActionUpdateCampaign), tokensWrite)
+	g.PUT("/campaigns/:campaign/disable", h.disableCampaign, audited(audit.ActionDisableCampaign), tokensWrite)
+	g.PUT("/campaigns/:campaign/enable", h.enableCampaign, audited(audit.ActionEnableCampaign), tokensWrite)
+	g.DELETE("/campaigns/:campaign", h.deleteCampaign, audited(audit.ActionDeleteCampaign), tokensWrite)
+
+	// lockouts of the public routes are managed with the same scopes as tokens
+	g.GET("/lockouts", lockout.NewListLockoutsHandler(lockoutSvc),
+		audited(audit.ActionListLockouts), tokensRead)
+	g.DELETE("/lockouts/:client", lockout.NewClearLockoutHandler(lockoutSvc),
+		audited(audit.ActionClearLockout), tokensWrite)
+}
+
+// PublicRateLimiters are the rate limiter stores of the public
+// routes, each route is throttled per client independently
+type PublicRateLimiters struct {
+	// Redeem is the rate limiter store of the redeem route
+	Redeem middleware.RateLimiterStore
+	// Validate is the rate limiter store of the validate route
+	Validate middleware.RateLimiterStore
 }
 
-// InitPublicRoutes initializes public routes
-func InitPublicRoutes(e *echo.Echo, tokenSvc Service) {
-	h := &publicHandler{tokenSvc: tokenSvc}
-	e.Use(newRateLimitMiddleware())
-	e.PUT("/tokens/:token/redeem", h.redeemToken)
+// publicBodyLimit is the max size of the public request bodies, it
+// leaves room for the redemption metadata and the other fields
+const publicBodyLimit = "16K"
+
+// InitPublicRoutes initializes public routes, the requests are
+// throttled per client ip using the buckets in the limiter stores and
+// clients are locked out after too many unknown token attempts
+func InitPublicRoutes(
+	e *echo.Echo,
+	tokenSvc Service,
+	lockoutSvc lockout.Service,
+	limiters PublicRateLimiters,
+) {
+	h := &publicHandler{tokenSvc: tokenSvc, lockoutSvc: lockoutSvc}
+	bodyLimit := middleware.BodyLimit(publicBodyLimit)
+	e.PUT("/tokens/:token/redeem", h.redeemToken,
+		newRateLimitMiddleware(limiters.Redeem, ipIdentifier), bodyLimit)
+	e.GET("/tokens/:token/validate", h.validateToken,
+		newRateLimitMiddleware(limiters.Validate, ipIdentifier), bodyLimit)
 }
 
 // adminHandler provides admin routes
@@ -39,35 +138,187 @@ type adminHandler struct {
 	tokenSvc Service
 }
 
+// genTokenRequest is the request for generating token
+type genTokenRequest struct {
+	Count          int                    `json:"count"`
+	MaxRedemptions int                    `json:"maxRedemptions"`
+	TTL            string                 `json:"ttl"`
+	ExpiresAt      *time.Time             `json:"expiresAt"`
+	NeverExpires   bool                   `json:"neverExpires"`
+	NotBefore      *time.Time             `json:"notBefore"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Labels         []string               `json:"labels"`
+	Campaign       CampaignID             `json:"campaign"`
+}
+
 // genTokenResponse is the response for generating token
 type genTokenResponse struct {
 	Token ID `json:"token"`
 }
 
+// genTokensResponse is the response for generating a batch of tokens
+type genTokensResponse struct {
+	Tokens []ID `json:"tokens"`
+}
+
 // generateTokens handles generate token request
 func (h *adminHandler) generateTokens(c echo.Context) error {
-	token, err := h.tokenSvc.GenerateToken(c.Request().Context())
+	var req genTokenRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid ttl")
+		}
+	}
+
+	ctx := c.Request().Context()
+	params := GenerateParams{
+		MaxRedemptions: req.MaxRedemptions,
+		TTL:            ttl,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+		NotBefore:      req.NotBefore,
+		Metadata:       req.Metadata,
+		Labels:         req.Labels,
+		Campaign:       req.Campaign,
+	}
+
+	// bulk mode
+	if req.Count != 0 {
+		tokens, err := h.tokenSvc.GenerateTokens(ctx, req.Count, params)
+		if err != nil {
+			if isGenerateParamsErr(err) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			if isCampaignConflictErr(err) {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+
+			return errors.Wrap(err, "generate tokens")
+		}
+
+		audit.SetTarget(c, joinIDs(tokens))
+		return c.JSON(http.StatusCreated, genTokensResponse{
+			Tokens: tokens,
+		})
+	}
+
+	token, err := h.tokenSvc.GenerateToken(ctx, params)
 	if err != nil {
+		if isGenerateParamsErr(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if isCampaignConflictErr(err) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
 		return errors.Wrap(err, "generate token")
 	}
 
+	audit.SetTarget(c, string(token))
 	return c.JSON(http.StatusCreated, genTokenResponse{
 		Token: token,
 	})
 }
 
+// joinIDs returns the comma separated token ids
+func joinIDs(ids []ID) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = string(id)
+	}
+
+	return strings.Join(strs, ",")
+}
+
+// isGenerateParamsErr returns true if err is caused by invalid generate params
+func isGenerateParamsErr(err error) bool {
+	return errors.Is(err, ErrInvalidMaxRedemptions) ||
+		errors.Is(err, ErrInvalidExpiration) ||
+		errors.Is(err, ErrInvalidNotBefore) ||
+		errors.Is(err, ErrInvalidCount) ||
+		errors.Is(err, ErrInvalidLabels) ||
+		errors.Is(err, ErrInvalidMetadata) ||
+		errors.Is(err, ErrCampaignNotFound)
+}
+
 // tokenResponse is a get token response
 type tokenResponse struct {
-	Token      ID        `json:"token"`
-	Redeemed   bool      `json:"redeemed"`
-	Disabled   bool      `json:"disabled"`
-	Expiration time.Time `json:"expiration"`
+	Token          ID                     `json:"token"`
+	Status         Status                 `json:"status"`
+	Redeemed       bool                   `json:"redeemed"`
+	RedeemedAt     *time.Time             `json:"redeemedAt"`
+	Disabled       bool                   `json:"disabled"`
+	Revoked        bool                   `json:"revoked"`
+	RevokeReason   string                 `json:"revokeReason,omitempty"`
+	Expiration     *time.Time             `json:"expiration"`
+	NotBefore      *time.Time             `json:"notBefore"`
+	MaxRedemptions int                    `json:"maxRedemptions"`
+	Redemptions    int                    `json:"redemptions"`
+	Remaining      int                    `json:"remaining"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Labels         []string               `json:"labels"`
+	Campaign       CampaignID             `json:"campaign,omitempty"`
+	CreatedAt      *time.Time             `json:"createdAt"`
+}
+
+// newTokenResponse returns a token response from a token
+func newTokenResponse(tk *Token) tokenResponse {
+	labels := tk.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	return tokenResponse{
+		Token:          tk.ID,
+		Status:         tk.Status(),
+		Redeemed:       tk.Redeemed(),
+		RedeemedAt:     tk.RedeemedAt,
+		Disabled:       tk.Disabled,
+		Revoked:        tk.Revoked(),
+		RevokeReason:   tk.RevokeReason,
+		Expiration:     tk.ExpiresAt,
+		NotBefore:      tk.NotBefore,
+		MaxRedemptions: tk.MaxRedemptions,
+		Redemptions:    tk.Redemptions,
+		Remaining:      tk.Remaining(),
+		Metadata:       tk.Metadata,
+		Labels:         labels,
+		Campaign:       tk.CampaignID,
+		CreatedAt:      tk.CreatedAt,
+	}
+}
+
+// redemptionResponse is a token redemption response
+type redemptionResponse struct {
+	ID        RedemptionID      `json:"id"`
+	UserID    string            `json:"userId"`
+	Email     string            `json:"email"`
+	Metadata  map[string]string `json:"metadata"`
+	IPAddress string            `json:"ipAddress"`
+	UserAgent string            `json:"userAgent"`
+	CreatedAt *time.Time        `json:"createdAt"`
+}
+
+// getTokenResponse is a get token response
+type getTokenResponse struct {
+	tokenResponse
+	Redemptions []redemptionResponse `json:"redemptions"`
 }
 
 // getToken handles get token request
 func (h *adminHandler) getToken(c echo.Context) error {
+	ctx := c.Request().Context()
 	tokenID := ID(c.Param("token"))
-	tk, err := h.tokenSvc.GetToken(c.Request().Context(), tokenID)
+	tk, err := h.tokenSvc.GetToken(ctx, tokenID)
 	if err != nil {
 		if errors.Is(err, ErrTokenNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, "token not found")
@@ -76,34 +327,141 @@ func (h *adminHandler) getToken(c echo.Context) error {
 		return errors.Wrap(err, "get token")
 	}
 
-	return c.JSON(http.StatusOK, tokenResponse{
-		Token:      tk.ID,
-		Redeemed:   tk.Redeemed(),
-		Disabled:   tk.Disabled,
-		Expiration: tk.Expiration(),
-	})
+	redemptions, err := h.tokenSvc.ListRedemptions(ctx, tokenID)
+	if err != nil {
+		return errors.Wrap(err, "list redemptions")
+	}
+
+	resp := getTokenResponse{
+		tokenResponse: newTokenResponse(tk),
+		Redemptions:   make([]redemptionResponse, 0, len(redemptions)),
+	}
+	for _, r := range redemptions {
+		resp.Redemptions = append(resp.Redemptions, redemptionResponse{
+			ID:        r.ID,
+			UserID:    r.UserID,
+			Email:     r.Email,
+			Metadata:  r.Metadata,
+			IPAddress: r.IPAddress,
+			UserAgent: r.UserAgent,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// listTokensResponse is the list tokens response
+type listTokensResponse struct {
+	Tokens     []tokenResponse `json:"tokens"`
+	NextCursor string          `json:"nextCursor,omitempty"`
 }
 
 // listTokens handles list token request
 func (h *adminHandler) listTokens(c echo.Context) error {
-	tokens, err := h.tokenSvc.ListTokens(c.Request().Context())
+	query, err := parseListQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	page, err := h.tokenSvc.ListTokens(c.Request().Context(), query)
 	if err != nil {
+		if errors.Is(err, ErrInvalidListQuery) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
 		return errors.Wrap(err, "list tokens")
 	}
 
-	resp := make([]tokenResponse, 0, len(tokens))
-	for _, tk := range tokens {
-		resp = append(resp, tokenResponse{
-			Token:      tk.ID,
-			Expiration: tk.Expiration(),
-			Redeemed:   tk.Redeemed(),
-			Disabled:   tk.Disabled,
-		})
+	resp := listTokensResponse{
+		Tokens: make([]tokenResponse, 0, len(page.Tokens)),
+	}
+	for _, tk := range page.Tokens {
+		resp.Tokens = append(resp.Tokens, newTokenResponse(tk))
+	}
+
+	if page.Next != nil {
+		resp.NextCursor = page.Next.String()
 	}
 
 	return c.JSON(http.StatusOK, resp)
 }
 
+// parseListQuery parses the list tokens query params
+func parseListQuery(c echo.Context) (ListQuery, error) {
+	query := ListQuery{
+		Status:   Status(c.QueryParam("status")),
+		Sort:     SortOrder(c.QueryParam("sort")),
+		Labels:   c.QueryParams()["label"],
+		Campaign: CampaignID(c.QueryParam("campaign")),
+	}
+
+	var err error
+	if s := c.QueryParam("limit"); s != "" {
+		query.Limit, err = strconv.Atoi(s)
+		if err != nil {
+			return query, errors.New("invalid limit")
+		}
+	}
+
+	if s := c.QueryParam("cursor"); s != "" {
+		query.After, err = ParseCursor(s)
+		if err != nil {
+			return query, err
+		}
+	}
+
+	for name, dst := range map[string]**time.Time{
+		"createdAfter":  &query.CreatedAfter,
+		"createdBefore": &query.CreatedBefore,
+	} {
+		s := c.QueryParam(name)
+		if s == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return query, errors.Errorf("invalid %s", name)
+		}
+		*dst = &t
+	}
+
+	return query, nil
+}
+
+// summaryResponse is the token summary response
+type summaryResponse struct {
+	Total    int `json:"total"`
+	Active   int `json:"active"`
+	Redeemed int `json:"redeemed"`
+	Disabled int `json:"disabled"`
+	Expired  int `json:"expired"`
+	Revoked  int `json:"revoked"`
+	Pending  int `json:"pending"`
+}
+
+// summarizeTokens handles token summary request
+func (h *adminHandler) summarizeTokens(c echo.Context) error {
+	summary, err := h.tokenSvc.SummarizeTokens(c.Request().Context())
+	if err != nil {
+		return errors.Wrap(err, "summarize tokens")
+	}
+
+	resp := summaryResponse{
+		Active:   summary[StatusActive],
+		Redeemed: summary[StatusRedeemed],
+		Disabled: summary[StatusDisabled],
+		Expired:  summary[StatusExpired],
+		Revoked:  summary[StatusRevoked],
+		Pending:  summary[StatusPending],
+	}
+	resp.Total = resp.Active + resp.Redeemed + resp.Disabled +
+		resp.Expired + resp.Revoked + resp.Pending
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 // disableToken handles disable token request
 func (h *adminHandler) disableToken(c echo.Context) error {
 	tokenID := ID(c.Param("token"))
@@ -121,24 +479,194 @@ func (h *adminHandler) disableToken(c echo.Context) error {
 	})
 }
 
+// enableToken handles enable token request
+func (h *adminHandler) enableToken(c echo.Context) error {
+	tokenID := ID(c.Param("token"))
+	err := h.tokenSvc.EnableToken(c.Request().Context(), tokenID)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "token not found")
+		}
+
+		if isConflictErr(err) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		return errors.Wrap(err, "enable token")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "token successfully enabled.",
+	})
+}
+
+// extendTokenRequest is the request for extending token expiration
+type extendTokenRequest struct {
+	TTL          string     `json:"ttl"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	NeverExpires bool       `json:"neverExpires"`
+}
+
+// extendToken handles extend token expiration request
+func (h *adminHandler) extendToken(c echo.Context) error {
+	var req extendTokenRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid ttl")
+		}
+	}
+
+	tokenID := ID(c.Param("token"))
+	err = h.tokenSvc.ExtendToken(c.Request().Context(), tokenID, ExtendParams{
+		TTL:          ttl,
+		ExpiresAt:    req.ExpiresAt,
+		NeverExpires: req.NeverExpires,
+	})
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "token not found")
+		}
+
+		if errors.Is(err, ErrInvalidExpiration) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if isConflictErr(err) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		return errors.Wrap(err, "extend token")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "token successfully extended.",
+	})
+}
+
+// revokeTokenRequest is the request for revoking token
+type revokeTokenRequest struct {
+	Reason string `json:"reason"`
+}
+
+// revokeToken handles revoke token request
+func (h *adminHandler) revokeToken(c echo.Context) error {
+	var req revokeTokenRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	tokenID := ID(c.Param("token"))
+	err = h.tokenSvc.RevokeToken(c.Request().Context(), tokenID, req.Reason)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "token not found")
+		}
+
+		if errors.Is(err, ErrInvalidRevokeReason) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if isConflictErr(err) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		return errors.Wrap(err, "revoke token")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "token successfully revoked.",
+	})
+}
+
+// isConflictErr returns true if err is caused by an
+// operation that is not allowed in the token's current state
+func isConflictErr(err error) bool {
+	return errors.Is(err, ErrTokenRevoked) ||
+		errors.Is(err, ErrTokenEnabled) ||
+		errors.Is(err, ErrTokenRedeemed) ||
+		errors.Is(err, ErrTokenExhausted)
+}
+
 // publicHandler provides public routes
 type publicHandler struct {
-	tokenSvc Service
+	tokenSvc   Service
+	lockoutSvc lockout.Service
+}
+
+// checkLockout returns an error with the retry-after
+// header set if the client is locked out
+func (h *publicHandler) checkLockout(c echo.Context) error {
+	retryAfter, err := h.lockoutSvc.Check(c.Request().Context(), c.RealIP())
+	if err != nil {
+		if errors.Is(err, lockout.ErrClientLocked) {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+			return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+		}
+
+		return errors.Wrap(err, "check lockout")
+	}
+
+	return nil
+}
+
+// recordFailure records an unknown token attempt of the client
+func (h *publicHandler) recordFailure(c echo.Context) error {
+	_, err := h.lockoutSvc.RecordFailure(c.Request().Context(), c.RealIP())
+	return errors.Wrap(err, "record failure")
+}
+
+// redeemTokenRequest is the request for redeeming token
+type redeemTokenRequest struct {
+	UserID   string            `json:"userId"`
+	Email    string            `json:"email"`
+	Metadata map[string]string `json:"metadata"`
 }
 
 // redeemToken handles redeem token request
 func (h *publicHandler) redeemToken(c echo.Context) error {
+	var req redeemTokenRequest
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	err = h.checkLockout(c)
+	if err != nil {
+		return err
+	}
+
 	tokenID := ID(c.Param("token"))
-	err := h.tokenSvc.RedeemToken(c.Request().Context(), tokenID)
+	err = h.tokenSvc.RedeemToken(c.Request().Context(), tokenID, RedeemParams{
+		UserID:    req.UserID,
+		Email:     req.Email,
+		Metadata:  req.Metadata,
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	})
 	if err != nil {
 		if errors.Is(err, ErrTokenNotFound) {
+			if err := h.recordFailure(c); err != nil {
+				return err
+			}
+
 			return echo.NewHTTPError(http.StatusNotFound, "token not found")
 		}
 
+		if errors.Is(err, ErrInvalidRedemption) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
 		// application error
-		if errors.Is(err, ErrTokenDisabled) ||
-			errors.Is(err, ErrTokenExpired) ||
-			errors.Is(err, ErrTokenRedeemed) {
+		if _, ok := ReasonOf(err); ok {
 			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
 		}
 
@@ -150,27 +678,68 @@ func (h *publicHandler) redeemToken(c echo.Context) error {
 	})
 }
 
-// newRateLimitMiddleware returns a new rate limit middleware
-func newRateLimitMiddleware() echo.MiddlewareFunc {
-	// NOTE: We can implement a custom rate-limiter store
-	// instead of using the default in-memory implementation
+// validateTokenResponse is the validate token response
+type validateTokenResponse struct {
+	Token   ID     `json:"token"`
+	Valid   bool   `json:"valid"`
+	Reason  Reason `json:"reason"`
+	Message string `json:"message"`
+}
+
+// validateToken handles validate token request, all validation
+// results including unknown tokens are returned with status ok
+func (h *publicHandler) validateToken(c echo.Context) error {
+	err := h.checkLockout(c)
+	if err != nil {
+		return err
+	}
+
+	tokenID := ID(c.Param("token"))
+	err = h.tokenSvc.ValidateToken(c.Request().Context(), tokenID)
+	reason, ok := ReasonOf(err)
+	if !ok {
+		return errors.Wrap(err, "validate token")
+	}
+
+	// validating is as good as redeeming for guessing tokens, partial
+	// codes checked while typing in a signup form are not counted
+	if reason == ReasonNotFound && tokenID.WellFormed() {
+		if err := h.recordFailure(c); err != nil {
+			return err
+		}
+	}
+
+	resp := validateTokenResponse{
+		Token:   tokenID,
+		Valid:   reason == ReasonValid,
+		Reason:  reason,
+		Message: "token is valid",
+	}
+	if err != nil {
+		resp.Message = errors.Cause(err).Error()
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// newRateLimitMiddleware returns a rate limit middleware
+// that throttles the requests per identifier
+func newRateLimitMiddleware(
+	store middleware.RateLimiterStore,
+	identifier middleware.Extractor,
+) echo.MiddlewareFunc {
 	config := middleware.RateLimiterConfig{
-		Skipper: middleware.DefaultSkipper,
-		Store: middleware.NewRateLimiterMemoryStoreWithConfig(
-			middleware.RateLimiterMemoryStoreConfig{
-				Rate:      10,
-				Burst:     30,
-				ExpiresIn: 3 * time.Minute,
-			},
-		),
-		IdentifierExtractor: func(ctx echo.Context) (string, error) {
-			id := ctx.RealIP()
-			return id, nil
-		},
+		Skipper:             middleware.DefaultSkipper,
+		Store:               store,
+		IdentifierExtractor: identifier,
 		ErrorHandler: func(context echo.Context, err error) error {
 			return context.JSON(http.StatusForbidden, nil)
 		},
 		DenyHandler: func(context echo.Context, identifier string, err error) error {
+			if err != nil {
+				return errors.Wrap(err, "rate limiter store")
+			}
+
 			return context.JSON(http.StatusTooManyRequests, echo.Map{
 				"message": "Too many requests",
 			})
@@ -179,3 +748,19 @@ func newRateLimitMiddleware() echo.MiddlewareFunc {
 
 	return middleware.RateLimiterWithConfig(config)
 }
+
+// ipIdentifier identifies the client by ip
+func ipIdentifier(c echo.Context) (string, error) {
+	return c.RealIP(), nil
+}
+
+// principalIdentifier identifies the authenticated caller, callers
+// are separated by authentication method as their ids may overlap
+func principalIdentifier(c echo.Context) (string, error) {
+	principal := authn.PrincipalFromContext(c)
+	if principal == nil {
+		return "", errors.New("unauthenticated request")
+	}
+
+	return principal.Method + ":" + principal.ID, nil
+}
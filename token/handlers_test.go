@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,33 +15,63 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/stevenferrer/invitesvc/audit"
 	"github.com/stevenferrer/invitesvc/authn"
-	"github.com/stevenferrer/invitesvc/postgres"
-	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/clientip"
+	"github.com/stevenferrer/invitesvc/lockout"
+	"github.com/stevenferrer/invitesvc/ratelimit"
+	"github.com/stevenferrer/invitesvc/storagetest"
 	"github.com/stevenferrer/invitesvc/token"
 )
 
 func TestHandlers(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	// migrate db
-	postgres.MustMigrate(db)
+	storagetest.Run(t, testHandlers)
+}
 
-	tokenRepo := postgres.NewTokenRepository(db)
+func testHandlers(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	tokenRepo := repos.Token
 	tokenSvc := token.NewService(tokenRepo)
 
-	authRepo := postgres.NewAuthRepository(db)
+	authRepo := repos.Auth
 	authSvc := authn.NewAuthService(authRepo)
 
+	auditRepo := repos.Audit
+	auditSvc := audit.NewService(auditRepo)
+
 	ctx := context.TODO()
-	authKey, err := authSvc.GenerateAuthKey(ctx)
+	authKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+		Scopes: authn.Scopes,
+	})
 	require.NoError(t, err)
 	assert.NotEmpty(t, authKey)
 
+	lockoutRepo := repos.Lockout
+	lockoutSvc := lockout.NewService(lockoutRepo)
+
+	publicLimiters := token.PublicRateLimiters{
+		Redeem:   repos.NewRateLimiterStore("redeem", ratelimit.DefaultConfig),
+		Validate: repos.NewRateLimiterStore("validate", ratelimit.DefaultConfig),
+	}
+
+	// admin calls are effectively not throttled, except in the rate limit tests
+	loose := ratelimit.Config{Rate: 1000, Burst: 1000, ExpiresIn: time.Minute}
+	adminLimiters := token.AdminRateLimiters{
+		Client:    ratelimit.NewMemoryStore(loose),
+		Principal: ratelimit.NewMemoryStore(loose),
+	}
+
+	// newPublicLimiters returns public rate limiters with separate buckets
+	newPublicLimiters := func() token.PublicRateLimiters {
+		return token.PublicRateLimiters{
+			Redeem:   ratelimit.NewMemoryStore(ratelimit.DefaultConfig),
+			Validate: ratelimit.NewMemoryStore(ratelimit.DefaultConfig),
+		}
+	}
+
 	e := echo.New()
-	token.InitAdminRoutes(e, tokenSvc, authSvc)
-	token.InitPublicRoutes(e, tokenSvc)
+	token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+	token.InitPublicRoutes(e, tokenSvc, lockoutSvc, publicLimiters)
 
 	t.Run("generate and retrieve token", func(t *testing.T) {
 		// generate token
@@ -67,18 +99,26 @@ func TestHandlers(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 
 		var resp2 = struct {
-			Token      string    `json:"token"`
-			Disabled   bool      `json:"disabled"`
-			Redeemed   bool      `json:"redeemed"`
-			Expiration time.Time `json:"expiration"`
+			Token      string     `json:"token"`
+			Disabled   bool       `json:"disabled"`
+			Redeemed   bool       `json:"redeemed"`
+			RedeemedAt *time.Time `json:"redeemedAt"`
+			Expiration time.Time  `json:"expiration"`
+			Remaining  int        `json:"remaining"`
+			Status     string     `json:"status"`
+			CreatedAt  time.Time  `json:"createdAt"`
 		}{}
 		err = json.NewDecoder(rr.Body).Decode(&resp2)
 		require.NoError(t, err)
+		assert.Equal(t, "active", resp2.Status)
 
 		assert.Equal(t, resp1.Token, resp2.Token)
 		assert.NotZero(t, resp2.Expiration)
+		assert.Equal(t, 1, resp2.Remaining)
 		assert.False(t, resp2.Redeemed)
+		assert.Nil(t, resp2.RedeemedAt)
 		assert.False(t, resp2.Disabled)
+		assert.NotZero(t, resp2.CreatedAt)
 
 		t.Run("token not found", func(t *testing.T) {
 			tokenID, err := token.NewID()
@@ -102,17 +142,21 @@ func TestHandlers(t *testing.T) {
 		e.ServeHTTP(rr, req)
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var resp = []struct {
-			Token      string    `json:"token"`
-			Redeemed   bool      `json:"redeemed"`
-			Disabled   bool      `json:"disabled"`
-			Expiration time.Time `json:"expiration"`
+		var resp = struct {
+			Tokens []struct {
+				Token      string    `json:"token"`
+				Redeemed   bool      `json:"redeemed"`
+				Disabled   bool      `json:"disabled"`
+				Expiration time.Time `json:"expiration"`
+			} `json:"tokens"`
+			NextCursor string `json:"nextCursor"`
 		}{}
 		err = json.NewDecoder(rr.Body).Decode(&resp)
 		require.NoError(t, err)
-		assert.Len(t, resp, 1)
+		assert.Len(t, resp.Tokens, 1)
+		assert.Empty(t, resp.NextCursor)
 
-		for _, res := range resp {
+		for _, res := range resp.Tokens {
 			assert.NotEmpty(t, res.Token)
 			assert.False(t, res.Redeemed)
 			assert.False(t, res.Disabled)
@@ -121,11 +165,11 @@ func TestHandlers(t *testing.T) {
 	})
 
 	t.Run("disable token", func(t *testing.T) {
-		tokens, err := tokenSvc.ListTokens(ctx)
+		page, err := tokenSvc.ListTokens(ctx, token.ListQuery{})
 		require.NoError(t, err)
-		assert.Len(t, tokens, 1)
+		assert.Len(t, page.Tokens, 1)
 		// use one token for testing
-		tk := tokens[0]
+		tk := page.Tokens[0]
 		urlStr := fmt.Sprintf("/admin/tokens/%s/disable", tk.ID)
 		req := httptest.NewRequest(http.MethodPut, urlStr, nil)
 		req.Header.Add(authn.AuthKeyHeader, string(authKey))
@@ -154,17 +198,352 @@ func TestHandlers(t *testing.T) {
 		})
 	})
 
+	t.Run("generate multi-use token", func(t *testing.T) {
+		body := strings.NewReader(`{"maxRedemptions": 5, "ttl": "72h"}`)
+		req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var resp = struct {
+			Token string `json:"token"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, token.ID(resp.Token))
+		require.NoError(t, err)
+		assert.Equal(t, 5, gotTk.MaxRedemptions)
+		assert.Equal(t, 5, gotTk.Remaining())
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(72*time.Hour), *gotTk.ExpiresAt, time.Minute)
+
+		t.Run("never expires", func(t *testing.T) {
+			body := strings.NewReader(`{"neverExpires": true}`)
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+
+			var resp = struct {
+				Token string `json:"token"`
+			}{}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+
+			gotTk, err := tokenSvc.GetToken(ctx, token.ID(resp.Token))
+			require.NoError(t, err)
+			assert.Nil(t, gotTk.ExpiresAt)
+		})
+
+		t.Run("invalid ttl", func(t *testing.T) {
+			body := strings.NewReader(`{"ttl": "1 week"}`)
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("invalid max redemptions", func(t *testing.T) {
+			body := strings.NewReader(`{"maxRedemptions": -1}`)
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+
+	t.Run("metadata and labels", func(t *testing.T) {
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+
+		body := strings.NewReader(`{"count": 3, "metadata": {"campaign": "meetup", "wave": 1}, 
+			"labels": ["meetup-2021", "berlin"]}`)
+		req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/admin/tokens?label=meetup-2021&label=berlin", nil)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		rr = httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Tokens []struct {
+				Token    string                 `json:"token"`
+				Metadata map[string]interface{} `json:"metadata"`
+				Labels   []string               `json:"labels"`
+			} `json:"tokens"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Tokens, 3)
+		for _, tk := range resp.Tokens {
+			assert.Equal(t, map[string]interface{}{"campaign": "meetup", "wave": float64(1)}, tk.Metadata)
+			assert.Equal(t, []string{"meetup-2021", "berlin"}, tk.Labels)
+		}
+
+		t.Run("invalid labels", func(t *testing.T) {
+			body := strings.NewReader(`{"labels": [" "]}`)
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+
+	t.Run("generate tokens in bulk", func(t *testing.T) {
+		body := strings.NewReader(`{"count": 50, "maxRedemptions": 2}`)
+		req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var resp = struct {
+			Tokens []string `json:"tokens"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Len(t, resp.Tokens, 50)
+
+		t.Run("list tokens page", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/tokens?limit=10&status=active&sort=desc", nil)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var resp = struct {
+				Tokens []struct {
+					Token string `json:"token"`
+				} `json:"tokens"`
+				NextCursor string `json:"nextCursor"`
+			}{}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Len(t, resp.Tokens, 10)
+			assert.NotEmpty(t, resp.NextCursor)
+
+			// next page
+			req = httptest.NewRequest(http.MethodGet, "/admin/tokens?limit=10&status=active&sort=desc&cursor="+resp.NextCursor, nil)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr = httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			// summary
+			req = httptest.NewRequest(http.MethodGet, "/admin/tokens/summary", nil)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr = httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var summary = struct {
+				Total    int `json:"total"`
+				Active   int `json:"active"`
+				Disabled int `json:"disabled"`
+			}{}
+			err = json.NewDecoder(rr.Body).Decode(&summary)
+			require.NoError(t, err)
+			assert.Equal(t, 1, summary.Disabled)
+			assert.GreaterOrEqual(t, summary.Active, 50)
+			assert.Equal(t, summary.Total, summary.Active+summary.Disabled)
+
+			// invalid query
+			for _, q := range []string{"status=unknown", "limit=abc", "cursor=abc", "createdAfter=yesterday"} {
+				req = httptest.NewRequest(http.MethodGet, "/admin/tokens?"+q, nil)
+				req.Header.Add(authn.AuthKeyHeader, string(authKey))
+				rr = httptest.NewRecorder()
+
+				e.ServeHTTP(rr, req)
+				assert.Equal(t, http.StatusBadRequest, rr.Code, q)
+			}
+		})
+
+		t.Run("invalid count", func(t *testing.T) {
+			body := strings.NewReader(fmt.Sprintf(`{"count": %d}`, token.MaxBatchSize+1))
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+
+	t.Run("enable, extend and revoke token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+
+		// put sends an admin request to the token action
+		put := func(action, body string) *httptest.ResponseRecorder {
+			urlStr := fmt.Sprintf("/admin/tokens/%s/%s", tokenID, action)
+			req := httptest.NewRequest(http.MethodPut, urlStr, strings.NewReader(body))
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		// token is not disabled
+		assert.Equal(t, http.StatusConflict, put("enable", "").Code)
+		assert.Equal(t, http.StatusOK, put("disable", "").Code)
+		assert.Equal(t, http.StatusOK, put("enable", "").Code)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.False(t, gotTk.Disabled)
+
+		assert.Equal(t, http.StatusBadRequest, put("extend", `{"ttl": "abc"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, put("extend", `{"ttl": "1h"}`).Code)
+		assert.Equal(t, http.StatusOK, put("extend", `{"ttl": "720h"}`).Code)
+
+		gotTk, err = tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(720*time.Hour), *gotTk.ExpiresAt, time.Minute)
+
+		assert.Equal(t, http.StatusBadRequest, put("revoke", `{"reason": ""}`).Code)
+		assert.Equal(t, http.StatusOK, put("revoke", `{"reason": "leaked"}`).Code)
+		assert.Equal(t, http.StatusConflict, put("revoke", `{"reason": "leaked"}`).Code)
+		assert.Equal(t, http.StatusConflict, put("extend", `{"neverExpires": true}`).Code)
+
+		// verify token update
+		req := httptest.NewRequest(http.MethodGet, "/admin/tokens/"+string(tokenID), nil)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Status       string `json:"status"`
+			Revoked      bool   `json:"revoked"`
+			RevokeReason string `json:"revokeReason"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "revoked", resp.Status)
+		assert.True(t, resp.Revoked)
+		assert.Equal(t, "leaked", resp.RevokeReason)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err = token.NewID()
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusNotFound, put("enable", "").Code)
+			assert.Equal(t, http.StatusNotFound, put("extend", `{"ttl": "1h"}`).Code)
+			assert.Equal(t, http.StatusNotFound, put("revoke", `{"reason": "leaked"}`).Code)
+		})
+	})
+
+	t.Run("scoped auth key", func(t *testing.T) {
+		readKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: []authn.Scope{authn.ScopeTokensRead},
+		})
+		require.NoError(t, err)
+
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+
+		for _, tc := range []struct {
+			method, target string
+			code           int
+		}{
+			{http.MethodGet, "/admin/tokens", http.StatusOK},
+			{http.MethodGet, "/admin/tokens/summary", http.StatusOK},
+			{http.MethodPost, "/admin/tokens", http.StatusForbidden},
+			{http.MethodPost, "/admin/authkey", http.StatusForbidden},
+			{http.MethodGet, "/admin/authkeys", http.StatusForbidden},
+		} {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			req.Header.Add(authn.AuthKeyHeader, string(readKey))
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, tc.code, rr.Code, tc.method+" "+tc.target)
+		}
+	})
+
 	t.Run("redeem token", func(t *testing.T) {
-		tk1, err := tokenSvc.GenerateToken(ctx)
+		tk1, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
 		require.NoError(t, err)
 
 		urlStr := fmt.Sprintf("/tokens/%s/redeem", tk1)
-		req := httptest.NewRequest(http.MethodPut, urlStr, nil)
+		body := strings.NewReader(`{"userId": "user-1", "email": "user@example.com"}`)
+		req := httptest.NewRequest(http.MethodPut, urlStr, body)
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Add("User-Agent", "invitesvc-test")
 		rr := httptest.NewRecorder()
 
 		e.ServeHTTP(rr, req)
 		assert.Equal(t, http.StatusOK, rr.Code)
 
+		t.Run("redemption history", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/tokens/"+string(tk1), nil)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var resp = struct {
+				Token       string `json:"token"`
+				Redemptions []struct {
+					UserID    string `json:"userId"`
+					Email     string `json:"email"`
+					IPAddress string `json:"ipAddress"`
+					UserAgent string `json:"userAgent"`
+				} `json:"redemptions"`
+			}{}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			require.Len(t, resp.Redemptions, 1)
+			assert.Equal(t, "user-1", resp.Redemptions[0].UserID)
+			assert.Equal(t, "user@example.com", resp.Redemptions[0].Email)
+			assert.NotEmpty(t, resp.Redemptions[0].IPAddress)
+			assert.Equal(t, "invitesvc-test", resp.Redemptions[0].UserAgent)
+		})
+
 		t.Run("error redeem", func(t *testing.T) {
 			urlStr = fmt.Sprintf("/tokens/%s/redeem", tk1)
 			req = httptest.NewRequest(http.MethodPut, urlStr, nil)
@@ -174,8 +553,40 @@ func TestHandlers(t *testing.T) {
 			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
 		})
 
+		t.Run("invalid redemption", func(t *testing.T) {
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+			require.NoError(t, err)
+
+			redeem := func(body string) *httptest.ResponseRecorder {
+				urlStr := fmt.Sprintf("/tokens/%s/redeem", tokenID)
+				req := httptest.NewRequest(http.MethodPut, urlStr, strings.NewReader(body))
+				req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				req.RemoteAddr = "192.0.2.20:1234"
+				rr := httptest.NewRecorder()
+
+				e.ServeHTTP(rr, req)
+				return rr
+			}
+
+			rr := redeem(fmt.Sprintf(`{"userId": %q}`, strings.Repeat("a", token.MaxUserIDLen+1)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			rr = redeem(fmt.Sprintf(`{"metadata": {"a": %q}}`, strings.Repeat("a", token.MaxMetadataSize)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			// the body size is limited
+			rr = redeem(fmt.Sprintf(`{"metadata": {"a": %q}}`, strings.Repeat("a", 32<<10)))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+			// nothing is stored
+			redemptions, err := tokenSvc.ListRedemptions(ctx, tokenID)
+			require.NoError(t, err)
+			assert.Empty(t, redemptions)
+		})
+
 		t.Run("rate limit", func(t *testing.T) {
-			for i := 0; i < 25; i++ {
+			// exhaust the redeem burst
+			for i := 0; i < ratelimit.DefaultConfig.Burst; i++ {
 				urlStr = fmt.Sprintf("/tokens/%s/redeem", tk1)
 				req = httptest.NewRequest(http.MethodPut, urlStr, nil)
 				rr = httptest.NewRecorder()
@@ -191,4 +602,673 @@ func TestHandlers(t *testing.T) {
 			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
 		})
 	})
+
+	t.Run("audit log", func(t *testing.T) {
+		readKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+			Scopes: []authn.Scope{authn.ScopeTokensRead},
+		})
+		require.NoError(t, err)
+
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+
+		do := func(method, target string, authKey authn.AuthKey) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, nil)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderXRequestID, "req-1")
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := do(http.MethodPost, "/admin/tokens", authKey)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var genResp = struct {
+			Token string `json:"token"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&genResp)
+		require.NoError(t, err)
+
+		rr = do(http.MethodPut, "/admin/tokens/"+genResp.Token+"/disable", readKey)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+
+		// the audit log requires the audit:read scope
+		rr = do(http.MethodGet, "/admin/audit", readKey)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodGet, "/admin/audit?target="+genResp.Token, authKey)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Events []audit.Event `json:"events"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Events, 2)
+
+		// newest first
+		forbidden, generated := resp.Events[0], resp.Events[1]
+		assert.Equal(t, audit.ActionDisableToken, forbidden.Action)
+		assert.Equal(t, string(readKey.ID()), forbidden.Actor)
+		assert.Equal(t, http.StatusForbidden, forbidden.Status)
+
+		assert.Equal(t, audit.ActionGenerateTokens, generated.Action)
+		assert.Equal(t, string(authKey.ID()), generated.Actor)
+		assert.Equal(t, authn.MethodAuthKey, generated.AuthMethod)
+		assert.Equal(t, http.StatusCreated, generated.Status)
+		assert.Equal(t, "req-1", generated.RequestID)
+		assert.NotEmpty(t, generated.IPAddress)
+		assert.NotNil(t, generated.CreatedAt)
+
+		post := func(target, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		// the target of a generated auth key is its key id
+		rr = post("/admin/authkey", `{"scopes": ["tokens:read"]}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var keyResp = struct {
+			KeyID string `json:"keyId"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&keyResp)
+		require.NoError(t, err)
+
+		rr = do(http.MethodGet, "/admin/audit?target="+keyResp.KeyID, authKey)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, audit.ActionGenerateAuthKey, resp.Events[0].Action)
+
+		// the target of a batch is its comma separated tokens
+		rr = post("/admin/tokens", `{"count": 2}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var batchResp = struct {
+			Tokens []string `json:"tokens"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&batchResp)
+		require.NoError(t, err)
+		require.Len(t, batchResp.Tokens, 2)
+
+		batch := strings.Join(batchResp.Tokens, ",")
+		rr = do(http.MethodGet, "/admin/audit?target="+batch, authKey)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, audit.ActionGenerateTokens, resp.Events[0].Action)
+
+		rr = do(http.MethodGet, "/admin/audit?limit=-1", authKey)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("campaigns", func(t *testing.T) {
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+		token.InitPublicRoutes(e, tokenSvc, lockoutSvc, newPublicLimiters())
+
+		do := func(method, target, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, strings.NewReader(body))
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		type campaignResp struct {
+			ID             string `json:"id"`
+			Name           string `json:"name"`
+			TTL            string `json:"ttl"`
+			MaxRedemptions int    `json:"maxRedemptions"`
+			MaxTokens      int    `json:"maxTokens"`
+			Tokens         int    `json:"tokens"`
+			Disabled       bool   `json:"disabled"`
+			Active         bool   `json:"active"`
+		}
+
+		rr := do(http.MethodPost, "/admin/campaigns", `{"name": "Meetup", 
+			"ttl": "48h", "maxRedemptions": 10, "maxTokens": 2}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var campaign campaignResp
+		err = json.NewDecoder(rr.Body).Decode(&campaign)
+		require.NoError(t, err)
+		assert.NotEmpty(t, campaign.ID)
+		assert.Equal(t, "48h0m0s", campaign.TTL)
+		assert.True(t, campaign.Active)
+
+		// generate token under the campaign
+		rr = do(http.MethodPost, "/admin/tokens", `{"campaign": "`+campaign.ID+`"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var genResp = struct {
+			Token string `json:"token"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&genResp)
+		require.NoError(t, err)
+
+		rr = do(http.MethodGet, "/admin/tokens?campaign="+campaign.ID, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var listResp = struct {
+			Tokens []struct {
+				Token          string `json:"token"`
+				Campaign       string `json:"campaign"`
+				MaxRedemptions int    `json:"maxRedemptions"`
+			} `json:"tokens"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&listResp)
+		require.NoError(t, err)
+		require.Len(t, listResp.Tokens, 1)
+		assert.Equal(t, genResp.Token, listResp.Tokens[0].Token)
+		assert.Equal(t, campaign.ID, listResp.Tokens[0].Campaign)
+		assert.Equal(t, 10, listResp.Tokens[0].MaxRedemptions)
+
+		// token limit
+		rr = do(http.MethodPost, "/admin/tokens", `{"count": 2, "campaign": "`+campaign.ID+`"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = do(http.MethodPut, "/admin/campaigns/"+campaign.ID, `{"name": "Meetup 2021", 
+			"maxRedemptions": 10, "maxTokens": 5}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		err = json.NewDecoder(rr.Body).Decode(&campaign)
+		require.NoError(t, err)
+		assert.Equal(t, "Meetup 2021", campaign.Name)
+		assert.Equal(t, 5, campaign.MaxTokens)
+		assert.Equal(t, 1, campaign.Tokens)
+
+		// tokens of a disabled campaign cannot be redeemed
+		rr = do(http.MethodPut, "/admin/campaigns/"+campaign.ID+"/disable", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = do(http.MethodPut, "/tokens/"+genResp.Token+"/redeem", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		rr = do(http.MethodPost, "/admin/tokens", `{"campaign": "`+campaign.ID+`"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = do(http.MethodPut, "/admin/campaigns/"+campaign.ID+"/enable", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = do(http.MethodPut, "/tokens/"+genResp.Token+"/redeem", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = do(http.MethodGet, "/admin/campaigns", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var campaignsResp = struct {
+			Campaigns []campaignResp `json:"campaigns"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&campaignsResp)
+		require.NoError(t, err)
+		require.Len(t, campaignsResp.Campaigns, 1)
+		assert.Equal(t, campaign.ID, campaignsResp.Campaigns[0].ID)
+
+		rr = do(http.MethodDelete, "/admin/campaigns/"+campaign.ID, "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		t.Run("errors", func(t *testing.T) {
+			notFound := string(token.NewCampaignID())
+			assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/campaigns/"+notFound, "").Code)
+			assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/admin/campaigns/"+notFound+"/disable", "").Code)
+			assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/campaigns/"+notFound, "").Code)
+			assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/campaigns", `{"name": ""}`).Code)
+			assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/campaigns", `{"name": "a", "ttl": "1x"}`).Code)
+			assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/tokens", `{"campaign": "`+notFound+`"}`).Code)
+		})
+	})
+
+	t.Run("not before", func(t *testing.T) {
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+		token.InitPublicRoutes(e, tokenSvc, lockoutSvc, newPublicLimiters())
+
+		notBefore := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		body := strings.NewReader(`{"notBefore": "` + notBefore + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rr := httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var genResp = struct {
+			Token string `json:"token"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&genResp)
+		require.NoError(t, err)
+
+		req = httptest.NewRequest(http.MethodGet, "/admin/tokens/"+genResp.Token, nil)
+		req.Header.Add(authn.AuthKeyHeader, string(authKey))
+		rr = httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Status    string     `json:"status"`
+			NotBefore *time.Time `json:"notBefore"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", resp.Status)
+		assert.NotNil(t, resp.NotBefore)
+
+		req = httptest.NewRequest(http.MethodPut, "/tokens/"+genResp.Token+"/redeem", nil)
+		rr = httptest.NewRecorder()
+
+		e.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), token.ErrTokenNotYetValid.Error())
+
+		t.Run("invalid not before", func(t *testing.T) {
+			body := strings.NewReader(`{"ttl": "1h", "notBefore": "` +
+				time.Now().Add(2*time.Hour).UTC().Format(time.RFC3339) + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/admin/tokens", body)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+
+	t.Run("validate token", func(t *testing.T) {
+		e := echo.New()
+		token.InitPublicRoutes(e, tokenSvc, lockoutSvc, newPublicLimiters())
+
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		validate := func(tokenID token.ID) (code int, resp struct {
+			Token  string `json:"token"`
+			Valid  bool   `json:"valid"`
+			Reason string `json:"reason"`
+		}) {
+			req := httptest.NewRequest(http.MethodGet, "/tokens/"+string(tokenID)+"/validate", nil)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			err := json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			return rr.Code, resp
+		}
+
+		code, resp := validate(tokenID)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, string(tokenID), resp.Token)
+		assert.True(t, resp.Valid)
+		assert.Equal(t, "valid", resp.Reason)
+
+		// validation has no side effects
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Zero(t, gotTk.Redemptions)
+
+		err = tokenSvc.DisableToken(ctx, tokenID)
+		require.NoError(t, err)
+
+		code, resp = validate(tokenID)
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, resp.Valid)
+		assert.Equal(t, "disabled", resp.Reason)
+
+		unknownID, err := token.NewID()
+		require.NoError(t, err)
+
+		code, resp = validate(unknownID)
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, resp.Valid)
+		assert.Equal(t, "not_found", resp.Reason)
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		lockoutSvc := lockout.NewService(lockoutRepo, lockout.WithPolicy(lockout.Policy{
+			MaxFailures: 2,
+			Duration:    time.Minute,
+			MaxDuration: time.Hour,
+			ResetAfter:  time.Hour,
+		}))
+
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+		token.InitPublicRoutes(e, tokenSvc, lockoutSvc, newPublicLimiters())
+
+		// a separate client so that the other subtests are not counted
+		client := "198.51.100.7"
+		do := func(method, target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, nil)
+			req.RemoteAddr = client + ":1234"
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{MaxRedemptions: 2})
+		require.NoError(t, err)
+
+		// successful redemptions are not counted
+		rr := do(http.MethodPut, "/tokens/"+string(tokenID)+"/redeem")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		unknownID, err := token.NewID()
+		require.NoError(t, err)
+
+		rr = do(http.MethodPut, "/tokens/"+string(unknownID)+"/redeem")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = do(http.MethodGet, "/tokens/"+string(unknownID)+"/validate")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// locked out, even for a valid token
+		rr = do(http.MethodPut, "/tokens/"+string(tokenID)+"/redeem")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.Greater(t, retryAfter, 0)
+		assert.LessOrEqual(t, retryAfter, 60)
+
+		rr = do(http.MethodGet, "/tokens/"+string(tokenID)+"/validate")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+		rr = do(http.MethodGet, "/admin/lockouts")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Lockouts []struct {
+				Client   string `json:"client"`
+				Failures int    `json:"failures"`
+				Locked   bool   `json:"locked"`
+			} `json:"lockouts"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+
+		var found bool
+		for _, l := range resp.Lockouts {
+			if l.Client == client {
+				found = true
+				assert.Equal(t, 2, l.Failures)
+				assert.True(t, l.Locked)
+			}
+		}
+		assert.True(t, found)
+
+		rr = do(http.MethodDelete, "/admin/lockouts/"+client)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = do(http.MethodDelete, "/admin/lockouts/"+client)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = do(http.MethodPut, "/tokens/"+string(tokenID)+"/redeem")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		t.Run("typing a code", func(t *testing.T) {
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+			require.NoError(t, err)
+
+			// partial codes are not counted
+			for i := 1; i < len(tokenID); i++ {
+				rr := do(http.MethodGet, "/tokens/"+string(tokenID[:i])+"/validate")
+				require.Equal(t, http.StatusOK, rr.Code, "code %q", tokenID[:i])
+			}
+
+			rr := do(http.MethodGet, "/tokens/"+string(tokenID)+"/validate")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp struct {
+				Valid bool `json:"valid"`
+			}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.True(t, resp.Valid)
+
+			rr = do(http.MethodGet, "/admin/lockouts")
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.NotContains(t, rr.Body.String(), client)
+
+			// full length unknown codes are still counted
+			unknownID, err := token.NewID()
+			require.NoError(t, err)
+
+			rr = do(http.MethodGet, "/tokens/"+string(unknownID)+"/validate")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			rr = do(http.MethodGet, "/admin/lockouts")
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), client)
+
+			rr = do(http.MethodDelete, "/admin/lockouts/"+client)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	})
+
+	t.Run("client ip", func(t *testing.T) {
+		lockoutSvc := lockout.NewService(lockoutRepo, lockout.WithPolicy(lockout.Policy{
+			MaxFailures: 2,
+			Duration:    time.Minute,
+			MaxDuration: time.Hour,
+			ResetAfter:  time.Hour,
+		}))
+
+		newEcho := func(strategy clientip.Strategy, trustedProxies string) *echo.Echo {
+			proxies, err := clientip.ParseTrustedProxies(trustedProxies)
+			require.NoError(t, err)
+
+			extractIP, err := clientip.NewExtractor(strategy, proxies)
+			require.NoError(t, err)
+
+			e := echo.New()
+			e.IPExtractor = extractIP
+			token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc, adminLimiters)
+			token.InitPublicRoutes(e, tokenSvc, lockoutSvc, newPublicLimiters())
+			return e
+		}
+
+		do := func(e *echo.Echo, method, target, remoteAddr, xff string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, xff)
+			req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		t.Run("spoofed headers", func(t *testing.T) {
+			e := newEcho(clientip.StrategyDirect, "")
+
+			// a different spoofed ip on every attempt doesn't avoid the lockout
+			for i := 1; i <= 2; i++ {
+				unknownID, err := token.NewID()
+				require.NoError(t, err)
+
+				rr := do(e, http.MethodPut, "/tokens/"+string(unknownID)+"/redeem",
+					"203.0.113.9:1234", fmt.Sprintf("198.51.100.%d", i))
+				assert.Equal(t, http.StatusNotFound, rr.Code)
+			}
+
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+			require.NoError(t, err)
+
+			rr := do(e, http.MethodPut, "/tokens/"+string(tokenID)+"/redeem",
+				"203.0.113.9:1234", "198.51.100.3")
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+			// the spoofed ip is not locked out
+			l, err := lockoutRepo.GetLockout(ctx, "203.0.113.9")
+			require.NoError(t, err)
+			assert.Equal(t, 2, l.Failures)
+
+			_, err = lockoutRepo.GetLockout(ctx, "198.51.100.1")
+			assert.ErrorIs(t, err, lockout.ErrLockoutNotFound)
+		})
+
+		t.Run("trusted proxy", func(t *testing.T) {
+			e := newEcho(clientip.StrategyXFF, "10.0.0.0/8")
+
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+			require.NoError(t, err)
+
+			// the nearest untrusted ip is the client ip
+			rr := do(e, http.MethodPut, "/tokens/"+string(tokenID)+"/redeem",
+				"10.0.0.2:1234", "198.51.100.1, 203.0.113.20")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			redemptions, err := tokenSvc.ListRedemptions(ctx, tokenID)
+			require.NoError(t, err)
+			require.Len(t, redemptions, 1)
+			assert.Equal(t, "203.0.113.20", redemptions[0].IPAddress)
+
+			rr = do(e, http.MethodGet, "/admin/tokens/"+string(tokenID),
+				"10.0.0.2:1234", "203.0.113.21")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			// the header is ignored when not sent by a trusted proxy
+			rr = do(e, http.MethodGet, "/admin/tokens/"+string(tokenID),
+				"203.0.113.22:1234", "198.51.100.1")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			page, err := auditSvc.ListEvents(ctx, audit.ListQuery{Target: string(tokenID)})
+			require.NoError(t, err)
+
+			var ips []string
+			for _, event := range page.Events {
+				ips = append(ips, event.IPAddress)
+			}
+			assert.ElementsMatch(t, []string{"203.0.113.21", "203.0.113.22"}, ips)
+		})
+	})
+
+	t.Run("rate limit policies", func(t *testing.T) {
+		tight := ratelimit.Config{Rate: 0.001, Burst: 2, ExpiresIn: time.Minute}
+
+		e := echo.New()
+		token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc,
+			token.AdminRateLimiters{
+				Client:    ratelimit.NewMemoryStore(loose),
+				Principal: ratelimit.NewMemoryStore(tight),
+			})
+		token.InitPublicRoutes(e, tokenSvc, lockoutSvc, token.PublicRateLimiters{
+			Redeem:   ratelimit.NewMemoryStore(tight),
+			Validate: ratelimit.NewMemoryStore(ratelimit.DefaultConfig),
+		})
+		e.GET("/ping", func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+
+		do := func(method, target string, authKey authn.AuthKey) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, nil)
+			if authKey != authn.NilAuthKey {
+				req.Header.Add(authn.AuthKeyHeader, string(authKey))
+			}
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+			return rr
+		}
+
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{MaxRedemptions: 5})
+		require.NoError(t, err)
+
+		t.Run("public routes", func(t *testing.T) {
+			redeemURL := "/tokens/" + string(tokenID) + "/redeem"
+			for i := 0; i < tight.Burst; i++ {
+				rr := do(http.MethodPut, redeemURL, authn.NilAuthKey)
+				assert.Equal(t, http.StatusOK, rr.Code)
+			}
+
+			rr := do(http.MethodPut, redeemURL, authn.NilAuthKey)
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+			// the other routes are not throttled by the redeem limiter
+			rr = do(http.MethodGet, "/tokens/"+string(tokenID)+"/validate", authn.NilAuthKey)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			for i := 0; i < 2*tight.Burst; i++ {
+				rr = do(http.MethodGet, "/ping", authn.NilAuthKey)
+				assert.Equal(t, http.StatusOK, rr.Code)
+			}
+		})
+
+		t.Run("admin routes", func(t *testing.T) {
+			otherKey, err := authSvc.GenerateAuthKey(ctx, authn.GenerateParams{
+				Scopes: []authn.Scope{authn.ScopeTokensRead},
+			})
+			require.NoError(t, err)
+
+			getURL := "/admin/tokens/" + string(tokenID)
+			for i := 0; i < tight.Burst; i++ {
+				rr := do(http.MethodGet, getURL, authKey)
+				assert.Equal(t, http.StatusOK, rr.Code)
+			}
+
+			rr := do(http.MethodGet, getURL, authKey)
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+			// admin calls are throttled per auth key
+			rr = do(http.MethodGet, getURL, otherKey)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			// unauthenticated calls are rejected before throttling per caller
+			rr = do(http.MethodGet, getURL, authn.NilAuthKey)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+
+		t.Run("admin routes per client ip", func(t *testing.T) {
+			e := echo.New()
+			token.InitAdminRoutes(e, tokenSvc, authSvc, auditSvc, lockoutSvc,
+				token.AdminRateLimiters{
+					Client:    ratelimit.NewMemoryStore(tight),
+					Principal: ratelimit.NewMemoryStore(loose),
+				})
+
+			do := func(remoteAddr string, authKey authn.AuthKey) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, "/admin/tokens/"+string(tokenID), nil)
+				req.RemoteAddr = remoteAddr
+				if authKey != authn.NilAuthKey {
+					req.Header.Add(authn.AuthKeyHeader, string(authKey))
+				}
+				rr := httptest.NewRecorder()
+
+				e.ServeHTTP(rr, req)
+				return rr
+			}
+
+			// bad and missing credentials are throttled as well
+			rr := do("192.0.2.10:1234", authn.AuthKey("invalid"))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			rr = do("192.0.2.10:1234", authn.NilAuthKey)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			rr = do("192.0.2.10:1234", authn.AuthKey("invalid"))
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+			rr = do("192.0.2.10:1234", authKey)
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+			// other clients are not throttled
+			rr = do("192.0.2.11:1234", authKey)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	})
 }
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// MaxLabels is the max number of labels per token
+	MaxLabels = 20
+	// maxLabelLen is the max len of a label
+	maxLabelLen = 64
+	// MaxMetadataSize is the max size of the json encoded token metadata
+	MaxMetadataSize = 8 << 10
+)
+
+// validLabels validates the labels and returns them
+// trimmed and without duplicates, never nil
+func validLabels(labels []string) ([]string, error) {
+	if len(labels) > MaxLabels {
+		return nil, ErrInvalidLabels
+	}
+
+	seen := make(map[string]struct{}, len(labels))
+	valid := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || len(label) > maxLabelLen {
+			return nil, ErrInvalidLabels
+		}
+
+		if _, ok := seen[label]; ok {
+			continue
+		}
+
+		seen[label] = struct{}{}
+		valid = append(valid, label)
+	}
+
+	return valid, nil
+}
+
+// validateMetadata returns an error if the metadata is too large
+func validateMetadata(metadata map[string]interface{}) error {
+	if metadata == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil || len(b) > MaxMetadataSize {
+		return ErrInvalidMetadata
+	}
+
+	return nil
+}
+
+// HasLabels returns true if the token has all the labels
+func (t *Token) HasLabels(labels ...string) bool {
+	for _, label := range labels {
+		found := false
+		for _, l := range t.Labels {
+			if l == label {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
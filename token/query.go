@@ -0,0 +1,91 @@
+package token
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultListLimit is the default number of tokens per page
+	DefaultListLimit = 50
+	// MaxListLimit is the max number of tokens per page
+	MaxListLimit = 1000
+)
+
+// SortOrder is the token list sort order
+type SortOrder string
+
+// List of sort orders, tokens are sorted by creation timestamp
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// ListQuery is the query for listing tokens
+type ListQuery struct {
+	// Status filters the tokens by status
+	Status Status
+	// CreatedAfter filters the tokens created at or after the timestamp
+	CreatedAfter *time.Time
+	// CreatedBefore filters the tokens created before the timestamp
+	CreatedBefore *time.Time
+	// Labels filters the tokens that have all the labels
+	Labels []string
+	// Campaign filters the tokens generated under the campaign
+	Campaign CampaignID
+	// Sort is the sort order, defaults to ascending
+	Sort SortOrder
+	// Limit is the max number of tokens, zero means no limit
+	Limit int
+	// After is the cursor of the last token in the previous page
+	After *Cursor
+}
+
+// Page is a page of tokens
+type Page struct {
+	// Tokens is the list of tokens
+	Tokens []*Token
+	// Next is the cursor for the next page, nil on the last page
+	Next *Cursor
+}
+
+// Cursor points to a token in the token list
+type Cursor struct {
+	CreatedAt time.Time
+	ID        ID
+}
+
+// cursorSep is the cursor field separator
+const cursorSep = "|"
+
+// String returns the encoded cursor
+func (c *Cursor) String() string {
+	s := c.CreatedAt.Format(time.RFC3339Nano) + cursorSep + string(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// ParseCursor parses an encoded cursor
+func ParseCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(b), cursorSep, 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: createdAt, ID: ID(parts[1])}, nil
+}
+
+// cursorOf returns the cursor of a token
+func cursorOf(tk *Token) *Cursor {
+	return &Cursor{CreatedAt: *tk.CreatedAt, ID: tk.ID}
+}
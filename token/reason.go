@@ -0,0 +1,50 @@
+package token
+
+import "github.com/pkg/errors"
+
+// Reason is a machine-readable token validation result
+type Reason string
+
+// List of token validation reasons
+const (
+	ReasonValid       Reason = "valid"
+	ReasonNotFound    Reason = "not_found"
+	ReasonRevoked     Reason = "revoked"
+	ReasonDisabled    Reason = "disabled"
+	ReasonExpired     Reason = "expired"
+	ReasonNotYetValid Reason = "not_yet_valid"
+	ReasonRedeemed    Reason = "redeemed"
+	ReasonExhausted   Reason = "exhausted"
+)
+
+// reasons maps the validation errors to their reasons, tokens
+// of a disabled campaign are reported as disabled
+var reasons = []struct {
+	err    error
+	reason Reason
+}{
+	{ErrTokenNotFound, ReasonNotFound},
+	{ErrTokenRevoked, ReasonRevoked},
+	{ErrTokenDisabled, ReasonDisabled},
+	{ErrCampaignDisabled, ReasonDisabled},
+	{ErrTokenExpired, ReasonExpired},
+	{ErrTokenNotYetValid, ReasonNotYetValid},
+	{ErrTokenRedeemed, ReasonRedeemed},
+	{ErrTokenExhausted, ReasonExhausted},
+}
+
+// ReasonOf returns the reason of a validation error, ok
+// is false when err is not caused by a validation rule
+func ReasonOf(err error) (reason Reason, ok bool) {
+	if err == nil {
+		return ReasonValid, true
+	}
+
+	for _, r := range reasons {
+		if errors.Is(err, r.err) {
+			return r.reason, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,79 @@
+package token
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxUserIDLen is the max len of the user id of a redemption
+	MaxUserIDLen = 256
+	// MaxEmailLen is the max len of the email of a redemption
+	MaxEmailLen = 254
+	// maxUserAgentLen is the max len of a stored user agent,
+	// longer user agents are truncated
+	maxUserAgentLen = 512
+)
+
+// RedemptionID is a redemption record id
+type RedemptionID string
+
+// NewRedemptionID returns a new redemption id
+func NewRedemptionID() RedemptionID {
+	return RedemptionID(uuid.NewString())
+}
+
+// Redemption is a token redemption record
+type Redemption struct {
+	// ID is the redemption id
+	ID RedemptionID `json:"id"`
+	// Token is the redeemed token
+	Token ID `json:"token"`
+	// UserID is the id of the user who redeemed the token
+	UserID string `json:"userId"`
+	// Email is the email of the user who redeemed the token
+	Email string `json:"email"`
+	// Metadata is the client metadata
+	Metadata map[string]string `json:"metadata"`
+	// IPAddress is the client ip address
+	IPAddress string `json:"ipAddress"`
+	// UserAgent is the client user agent
+	UserAgent string `json:"userAgent"`
+	// CreatedAt is the redeem timestamp
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// validateRedeemParams returns an error if the redeem
+// parameters set by the client are too large
+func validateRedeemParams(params RedeemParams) error {
+	if len(params.UserID) > MaxUserIDLen || len(params.Email) > MaxEmailLen {
+		return ErrInvalidRedemption
+	}
+
+	if params.Metadata == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(params.Metadata)
+	if err != nil || len(b) > MaxMetadataSize {
+		return ErrInvalidRedemption
+	}
+
+	return nil
+}
+
+// truncate truncates s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -1,17 +1,53 @@
 package token
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository is a token repository
 type Repository interface {
 	// CreateToken creates a token
-	CreateToken(context.Context, ID) error
+	CreateToken(context.Context, *Token) error
+	// CreateTokens creates tokens in a single transaction, nothing
+	// is created and ErrDuplicateToken is returned if any token exists.
+	// The token count of the campaign is incremented in the same
+	// transaction, ErrCampaignFull is returned if the campaign
+	// token limit is exceeded.
+	CreateTokens(context.Context, []*Token) error
 	// GetToken retrieves a token from db
 	GetToken(context.Context, ID) (*Token, error)
-	// ListTokens retrieves list of tokens from db
-	ListTokens(context.Context) ([]*Token, error)
+	// ListTokens retrieves list of tokens matching the query from db
+	ListTokens(context.Context, ListQuery) ([]*Token, error)
+	// CountTokens retrieves the number of tokens per status from db
+	CountTokens(context.Context) (map[Status]int, error)
 	// SetTokenDisabled sets a token to disabled
 	SetTokenDisabled(context.Context, ID) error
-	// SetTokenRedeemed sets a token to redeemed
-	SetTokenRedeemed(context.Context, ID) error
+	// SetTokenEnabled sets a token to not disabled
+	SetTokenEnabled(context.Context, ID) error
+	// SetTokenExpiration sets the token expiration, nil if never expires
+	SetTokenExpiration(context.Context, ID, *time.Time) error
+	// SetTokenRevoked sets a token to revoked with the given reason
+	SetTokenRevoked(context.Context, ID, string) error
+	// RedeemToken atomically sets a token to redeemed and saves the
+	// redemption record. It only succeeds when the token is not revoked,
+	// not disabled, not expired, already redeemable and has remaining
+	// redemptions, otherwise it returns the error of the rule that failed.
+	RedeemToken(context.Context, *Redemption) error
+	// ListRedemptions retrieves the redemption records of a token
+	ListRedemptions(context.Context, ID) ([]*Redemption, error)
+
+	// CreateCampaign creates a campaign
+	CreateCampaign(context.Context, *Campaign) error
+	// GetCampaign retrieves a campaign from db
+	GetCampaign(context.Context, CampaignID) (*Campaign, error)
+	// ListCampaigns retrieves all campaigns from db
+	ListCampaigns(context.Context) ([]*Campaign, error)
+	// UpdateCampaign updates the campaign settings
+	UpdateCampaign(context.Context, *Campaign) error
+	// SetCampaignDisabled sets a campaign to disabled or not disabled
+	SetCampaignDisabled(context.Context, CampaignID, bool) error
+	// DeleteCampaign deletes a campaign, ErrCampaignHasTokens
+	// is returned if tokens were generated under the campaign
+	DeleteCampaign(context.Context, CampaignID) error
 }
@@ -2,6 +2,8 @@ package token
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -9,43 +11,335 @@ import (
 // Service is an invite service
 type Service interface {
 	// GenerateToken generates new invite token
-	GenerateToken(context.Context) (ID, error)
+	GenerateToken(context.Context, GenerateParams) (ID, error)
+	// GenerateTokens generates a batch of invite tokens
+	GenerateTokens(context.Context, int, GenerateParams) ([]ID, error)
 	// GetToken retrieves an invite token
 	GetToken(context.Context, ID) (*Token, error)
-	// ListTokens retrieves the list of invite tokens
-	ListTokens(context.Context) ([]*Token, error)
+	// ListTokens retrieves a page of invite tokens
+	ListTokens(context.Context, ListQuery) (*Page, error)
+	// SummarizeTokens retrieves the number of invite tokens per status
+	SummarizeTokens(context.Context) (map[Status]int, error)
 	// DisableToken is used to disable an invite token
 	DisableToken(context.Context, ID) error
+	// EnableToken is used to re-enable a disabled invite token
+	EnableToken(context.Context, ID) error
+	// ExtendToken is used to extend the expiration of an invite token
+	ExtendToken(context.Context, ID, ExtendParams) error
+	// RevokeToken is used to permanently revoke an invite token
+	RevokeToken(context.Context, ID, string) error
+	// ValidateToken is used to check if an invite token
+	// can be redeemed without redeeming it
+	ValidateToken(context.Context, ID) error
 	// RedeemToken is used to redeem an invite token
-	RedeemToken(context.Context, ID) error
+	RedeemToken(context.Context, ID, RedeemParams) error
+	// ListRedemptions retrieves the redemption history of an invite token
+	ListRedemptions(context.Context, ID) ([]*Redemption, error)
+
+	// CreateCampaign creates a new invite campaign
+	CreateCampaign(context.Context, CampaignParams) (*Campaign, error)
+	// GetCampaign retrieves an invite campaign
+	GetCampaign(context.Context, CampaignID) (*Campaign, error)
+	// ListCampaigns retrieves all invite campaigns
+	ListCampaigns(context.Context) ([]*Campaign, error)
+	// UpdateCampaign updates the settings of an invite campaign
+	UpdateCampaign(context.Context, CampaignID, CampaignParams) (*Campaign, error)
+	// DisableCampaign is used to disable an invite campaign and its tokens
+	DisableCampaign(context.Context, CampaignID) error
+	// EnableCampaign is used to re-enable a disabled invite campaign
+	EnableCampaign(context.Context, CampaignID) error
+	// DeleteCampaign is used to delete an invite campaign without tokens
+	DeleteCampaign(context.Context, CampaignID) error
+}
+
+// maxGenerateAttempts is the max number of attempts
+// when generating tokens with colliding ids
+const maxGenerateAttempts = 3
+
+// GenerateParams is the token generation parameters
+type GenerateParams struct {
+	// MaxRedemptions is the number of times the token can be
+	// redeemed, defaults to 1 (single-use) when not set
+	MaxRedemptions int
+	// TTL is the token time-to-live, defaults
+	// to the service default TTL when not set
+	TTL time.Duration
+	// ExpiresAt is the token expiration timestamp
+	ExpiresAt *time.Time
+	// NeverExpires is used for creating tokens that never expires
+	NeverExpires bool
+	// NotBefore is the timestamp the token can be redeemed from
+	NotBefore *time.Time
+	// Metadata is free-form metadata stored with the token
+	Metadata map[string]interface{}
+	// Labels is the list of labels used for filtering tokens
+	Labels []string
+	// Campaign is the campaign of the token, the token inherits
+	// the campaign settings that are not set in the params
+	Campaign CampaignID
+}
+
+// ExtendParams is the token expiration extension parameters,
+// exactly one of the fields must be set
+type ExtendParams struct {
+	// TTL is the new token time-to-live, starting from now
+	TTL time.Duration
+	// ExpiresAt is the new token expiration timestamp
+	ExpiresAt *time.Time
+	// NeverExpires is used for making the token never expire
+	NeverExpires bool
+}
+
+// RedeemParams is the token redemption parameters
+type RedeemParams struct {
+	// UserID is the id of the user redeeming the token
+	UserID string
+	// Email is the email of the user redeeming the token
+	Email string
+	// Metadata is the client metadata
+	Metadata map[string]string
+	// IPAddress is the client ip address
+	IPAddress string
+	// UserAgent is the client user agent
+	UserAgent string
 }
 
 // tokenService implements token service
 type tokenService struct {
-	repo Repository
+	repo       Repository
+	defaultTTL time.Duration
 }
 
 var _ Service = (*tokenService)(nil)
 
+// Option is a token service option
+type Option func(*tokenService)
+
+// WithDefaultTTL sets the default token time-to-live
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(svc *tokenService) {
+		svc.defaultTTL = ttl
+	}
+}
+
 // NewService returns a new token service
-func NewService(tokenRepo Repository) Service {
-	return &tokenService{repo: tokenRepo}
+func NewService(tokenRepo Repository, opts ...Option) Service {
+	svc := &tokenService{repo: tokenRepo, defaultTTL: DefaultTTL}
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
 }
 
 // GenerateToken generates a new token
-func (svc *tokenService) GenerateToken(ctx context.Context) (ID, error) {
-	id, err := NewID()
+func (svc *tokenService) GenerateToken(ctx context.Context, params GenerateParams) (ID, error) {
+	tk, err := svc.newToken(ctx, params)
 	if err != nil {
-		return NilID, errors.Wrap(err, "new id")
+		return NilID, err
 	}
 
 	// save token
-	err = svc.repo.CreateToken(ctx, id)
+	err = svc.repo.CreateToken(ctx, tk)
 	if err != nil {
 		return NilID, errors.Wrap(err, "create token")
 	}
 
-	return id, nil
+	return tk.ID, nil
+}
+
+// GenerateTokens generates tokens in a single batch
+func (svc *tokenService) GenerateTokens(ctx context.Context, count int, params GenerateParams) ([]ID, error) {
+	if count < 1 || count > MaxBatchSize {
+		return nil, ErrInvalidCount
+	}
+
+	tmpl, err := svc.newToken(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// retry with new ids when some of the ids already exists
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		ids, err := newUniqueIDs(count)
+		if err != nil {
+			return nil, errors.Wrap(err, "new ids")
+		}
+
+		tokens := make([]*Token, 0, count)
+		for _, id := range ids {
+			tk := *tmpl
+			tk.ID = id
+			tokens = append(tokens, &tk)
+		}
+
+		err = svc.repo.CreateTokens(ctx, tokens)
+		if err != nil {
+			if errors.Is(err, ErrDuplicateToken) {
+				continue
+			}
+
+			return nil, errors.Wrap(err, "create tokens")
+		}
+
+		return ids, nil
+	}
+
+	return nil, errors.Errorf("generate tokens: id collision after %d attempts", maxGenerateAttempts)
+}
+
+// newToken validates the generate params and returns a new token
+func (svc *tokenService) newToken(ctx context.Context, params GenerateParams) (*Token, error) {
+	maxRedemptions := params.MaxRedemptions
+	if maxRedemptions < 0 {
+		return nil, ErrInvalidMaxRedemptions
+	}
+
+	var (
+		campaign *Campaign
+		err      error
+	)
+	ttl := params.TTL
+	if params.Campaign != NilCampaignID {
+		campaign, err = svc.activeCampaign(ctx, params.Campaign)
+		if err != nil {
+			return nil, err
+		}
+
+		// inherit the campaign settings
+		if maxRedemptions == 0 {
+			maxRedemptions = campaign.MaxRedemptions
+		}
+
+		if numExpirationSet(ttl, params.ExpiresAt, params.NeverExpires) == 0 {
+			ttl = campaign.TTL
+		}
+	}
+
+	if maxRedemptions == 0 {
+		maxRedemptions = 1
+	}
+
+	expiresAt, err := svc.expiresAt(ttl,
+		params.ExpiresAt, params.NeverExpires)
+	if err != nil {
+		return nil, err
+	}
+
+	// campaign tokens expire no later than the end of the campaign
+	if campaign != nil && campaign.EndsAt != nil &&
+		(expiresAt == nil || expiresAt.After(*campaign.EndsAt)) {
+		endsAt := *campaign.EndsAt
+		expiresAt = &endsAt
+	}
+
+	// campaign tokens cannot be redeemed before the start of the campaign
+	notBefore := params.NotBefore
+	if campaign != nil && campaign.StartsAt != nil &&
+		(notBefore == nil || notBefore.Before(*campaign.StartsAt)) {
+		notBefore = campaign.StartsAt
+	}
+
+	if notBefore != nil {
+		nb := *notBefore
+		notBefore = &nb
+		if expiresAt != nil && !nb.Before(*expiresAt) {
+			return nil, ErrInvalidNotBefore
+		}
+	}
+
+	labels, err := validLabels(params.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateMetadata(params.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := NewID()
+	if err != nil {
+		return nil, errors.Wrap(err, "new id")
+	}
+
+	return &Token{
+		ID:             id,
+		MaxRedemptions: maxRedemptions,
+		ExpiresAt:      expiresAt,
+		NotBefore:      notBefore,
+		Metadata:       params.Metadata,
+		Labels:         labels,
+		CampaignID:     params.Campaign,
+	}, nil
+}
+
+// activeCampaign retrieves a campaign and returns an
+// error if tokens cannot be generated under the campaign
+func (svc *tokenService) activeCampaign(ctx context.Context, id CampaignID) (*Campaign, error) {
+	campaign, err := svc.repo.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get campaign")
+	}
+
+	if campaign.Disabled {
+		return nil, ErrCampaignDisabled
+	}
+
+	// tokens can be generated before the campaign starts
+	if campaign.Ended() {
+		return nil, ErrCampaignEnded
+	}
+
+	// the limit is enforced by the repository,
+	// this only avoids generating ids needlessly
+	if campaign.Full() {
+		return nil, ErrCampaignFull
+	}
+
+	return campaign, nil
+}
+
+// numExpirationSet returns the number of expiration options that are set
+func numExpirationSet(ttl time.Duration, at *time.Time, neverExpires bool) int {
+	n := 0
+	for _, set := range []bool{ttl != 0, at != nil, neverExpires} {
+		if set {
+			n++
+		}
+	}
+
+	return n
+}
+
+// expiresAt returns the token expiration from the expiration options,
+// the default ttl is used when none of the options are set
+func (svc *tokenService) expiresAt(ttl time.Duration, at *time.Time, neverExpires bool) (*time.Time, error) {
+	// only one of ttl, expires at and never expires can be set
+	if numExpirationSet(ttl, at, neverExpires) > 1 {
+		return nil, ErrInvalidExpiration
+	}
+
+	now := time.Now()
+	switch {
+	case neverExpires:
+		return nil, nil
+	case at != nil:
+		if !at.After(now) {
+			return nil, ErrInvalidExpiration
+		}
+
+		expiresAt := *at
+		return &expiresAt, nil
+	case ttl < 0:
+		return nil, ErrInvalidExpiration
+	case ttl > 0:
+		expiresAt := now.Add(ttl)
+		return &expiresAt, nil
+	}
+
+	expiresAt := now.Add(svc.defaultTTL)
+	return &expiresAt, nil
 }
 
 // GetToken retrieves a token
@@ -58,10 +352,59 @@ func (svc *tokenService) GetToken(ctx context.Context, id ID) (*Token, error) {
 	return token, nil
 }
 
-// ListTokens retrives list of tokens
-func (svc *tokenService) ListTokens(ctx context.Context) ([]*Token, error) {
-	tokens, err := svc.repo.ListTokens(ctx)
-	return tokens, errors.Wrap(err, "list tokens")
+// ListTokens retrives a page of tokens
+func (svc *tokenService) ListTokens(ctx context.Context, query ListQuery) (*Page, error) {
+	if query.Status != "" && !query.Status.Valid() {
+		return nil, ErrInvalidListQuery
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = SortAsc
+	case SortAsc, SortDesc:
+	default:
+		return nil, ErrInvalidListQuery
+	}
+
+	if query.Limit < 0 || query.Limit > MaxListLimit {
+		return nil, ErrInvalidListQuery
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+
+	// fetch one more to know if there's a next page
+	query.Limit = limit + 1
+	tokens, err := svc.repo.ListTokens(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "list tokens")
+	}
+
+	page := &Page{Tokens: tokens}
+	if len(tokens) > limit {
+		page.Tokens = tokens[:limit]
+		page.Next = cursorOf(page.Tokens[limit-1])
+	}
+
+	return page, nil
+}
+
+// SummarizeTokens retrieves the number of tokens per status
+func (svc *tokenService) SummarizeTokens(ctx context.Context) (map[Status]int, error) {
+	counts, err := svc.repo.CountTokens(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "count tokens")
+	}
+
+	// include statuses without tokens
+	summary := make(map[Status]int, len(Statuses))
+	for _, status := range Statuses {
+		summary[status] = counts[status]
+	}
+
+	return summary, nil
 }
 
 // DisableToken disables a token
@@ -69,20 +412,219 @@ func (svc *tokenService) DisableToken(ctx context.Context, id ID) error {
 	return svc.repo.SetTokenDisabled(ctx, id)
 }
 
-// RedeemToken redeems a token
-func (svc *tokenService) RedeemToken(ctx context.Context, id ID) error {
+// EnableToken re-enables a disabled token, revoked and
+// fully redeemed tokens cannot be re-enabled
+func (svc *tokenService) EnableToken(ctx context.Context, id ID) error {
+	tk, err := svc.repo.GetToken(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "get token")
+	}
+
+	if tk.Revoked() {
+		return ErrTokenRevoked
+	}
+
+	if !tk.Disabled {
+		return ErrTokenEnabled
+	}
+
+	err = tk.validateRemaining()
+	if err != nil {
+		return err
+	}
+
+	return svc.repo.SetTokenEnabled(ctx, id)
+}
+
+// ExtendToken extends the expiration of a token, the new
+// expiration must be later than the current expiration
+func (svc *tokenService) ExtendToken(ctx context.Context, id ID, params ExtendParams) error {
+	if numExpirationSet(params.TTL, params.ExpiresAt, params.NeverExpires) != 1 {
+		return ErrInvalidExpiration
+	}
+
+	expiresAt, err := svc.expiresAt(params.TTL,
+		params.ExpiresAt, params.NeverExpires)
+	if err != nil {
+		return err
+	}
+
+	tk, err := svc.repo.GetToken(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "get token")
+	}
+
+	if tk.Revoked() {
+		return ErrTokenRevoked
+	}
+
+	err = tk.validateRemaining()
+	if err != nil {
+		return err
+	}
+
+	// token already never expires
+	if tk.ExpiresAt == nil {
+		return ErrInvalidExpiration
+	}
+
+	if expiresAt != nil && !expiresAt.After(*tk.ExpiresAt) {
+		return ErrInvalidExpiration
+	}
+
+	return svc.repo.SetTokenExpiration(ctx, id, expiresAt)
+}
+
+// RevokeToken permanently revokes a token with the given reason
+func (svc *tokenService) RevokeToken(ctx context.Context, id ID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrInvalidRevokeReason
+	}
+
+	tk, err := svc.repo.GetToken(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "get token")
+	}
+
+	if tk.Revoked() {
+		return ErrTokenRevoked
+	}
+
+	return svc.repo.SetTokenRevoked(ctx, id, reason)
+}
+
+// ValidateToken returns the error of the first validation
+// rule that fails, the token is left untouched
+func (svc *tokenService) ValidateToken(ctx context.Context, id ID) error {
 	tk, err := svc.repo.GetToken(ctx, id)
 	if err != nil {
 		return errors.Wrap(err, "get token")
 	}
 
-	// validate token
-	err = tk.Validate()
+	return tk.Validate()
+}
+
+// RedeemToken redeems a token
+func (svc *tokenService) RedeemToken(ctx context.Context, id ID, params RedeemParams) error {
+	err := validateRedeemParams(params)
 	if err != nil {
 		return err
 	}
 
-	// redeem token
-	err = svc.repo.SetTokenRedeemed(ctx, tk.ID)
-	return errors.Wrap(err, "set token redeemed")
+	// validation and redemption is done by the repository
+	// in a single operation to prevent double redemption
+	return svc.repo.RedeemToken(ctx, &Redemption{
+		ID:        NewRedemptionID(),
+		Token:     id,
+		UserID:    params.UserID,
+		Email:     params.Email,
+		Metadata:  params.Metadata,
+		IPAddress: params.IPAddress,
+		UserAgent: truncate(params.UserAgent, maxUserAgentLen),
+	})
+}
+
+// ListRedemptions retrieves the redemption history of a token
+func (svc *tokenService) ListRedemptions(ctx context.Context, id ID) ([]*Redemption, error) {
+	// make sure token exists
+	_, err := svc.repo.GetToken(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get token")
+	}
+
+	redemptions, err := svc.repo.ListRedemptions(ctx, id)
+	return redemptions, errors.Wrap(err, "list redemptions")
+}
+
+// CreateCampaign creates a new campaign
+func (svc *tokenService) CreateCampaign(ctx context.Context, params CampaignParams) (*Campaign, error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := &Campaign{
+		ID:             NewCampaignID(),
+		Name:           params.Name,
+		TTL:            params.TTL,
+		MaxRedemptions: params.MaxRedemptions,
+		StartsAt:       params.StartsAt,
+		EndsAt:         params.EndsAt,
+		MaxTokens:      params.MaxTokens,
+	}
+	err = svc.repo.CreateCampaign(ctx, campaign)
+	if err != nil {
+		return nil, errors.Wrap(err, "create campaign")
+	}
+
+	return campaign, nil
+}
+
+// GetCampaign retrieves a campaign
+func (svc *tokenService) GetCampaign(ctx context.Context, id CampaignID) (*Campaign, error) {
+	campaign, err := svc.repo.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get campaign")
+	}
+
+	return campaign, nil
+}
+
+// ListCampaigns retrieves all campaigns
+func (svc *tokenService) ListCampaigns(ctx context.Context) ([]*Campaign, error) {
+	campaigns, err := svc.repo.ListCampaigns(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list campaigns")
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates the campaign settings, the settings only
+// apply to tokens generated after the update. The token limit
+// cannot be lower than the number of tokens already generated.
+func (svc *tokenService) UpdateCampaign(ctx context.Context, id CampaignID, params CampaignParams) (*Campaign, error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	campaign, err := svc.repo.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get campaign")
+	}
+
+	if params.MaxTokens > 0 && params.MaxTokens < campaign.Tokens {
+		return nil, ErrInvalidCampaign
+	}
+
+	campaign.Name = params.Name
+	campaign.TTL = params.TTL
+	campaign.MaxRedemptions = params.MaxRedemptions
+	campaign.StartsAt = params.StartsAt
+	campaign.EndsAt = params.EndsAt
+	campaign.MaxTokens = params.MaxTokens
+	err = svc.repo.UpdateCampaign(ctx, campaign)
+	if err != nil {
+		return nil, errors.Wrap(err, "update campaign")
+	}
+
+	return svc.GetCampaign(ctx, id)
+}
+
+// DisableCampaign disables a campaign, tokens of a
+// disabled campaign cannot be redeemed
+func (svc *tokenService) DisableCampaign(ctx context.Context, id CampaignID) error {
+	return svc.repo.SetCampaignDisabled(ctx, id, true)
+}
+
+// EnableCampaign re-enables a disabled campaign
+func (svc *tokenService) EnableCampaign(ctx context.Context, id CampaignID) error {
+	return svc.repo.SetCampaignDisabled(ctx, id, false)
+}
+
+// DeleteCampaign deletes a campaign without tokens
+func (svc *tokenService) DeleteCampaign(ctx context.Context, id CampaignID) error {
+	return svc.repo.DeleteCampaign(ctx, id)
 }
@@ -2,29 +2,30 @@ package token_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/stevenferrer/invitesvc/postgres"
-	"github.com/stevenferrer/invitesvc/postgres/txdb"
+	"github.com/stevenferrer/invitesvc/storagetest"
 	"github.com/stevenferrer/invitesvc/token"
 )
 
 func TestService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	// migrate db
-	postgres.MustMigrate(db)
+	storagetest.Run(t, testService)
+}
 
-	tokenRepo := postgres.NewTokenRepository(db)
+func testService(t *testing.T, b storagetest.Backend) {
+	repos := b.Open(t)
+	tokenRepo := repos.Token
 	tokenSvc := token.NewService(tokenRepo)
 
 	ctx := context.TODO()
 	t.Run("generate and retrieve token", func(t *testing.T) {
-		tokenID, err := tokenSvc.GenerateToken(ctx)
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
 		require.NoError(t, err)
 		require.NotEmpty(t, tokenID)
 
@@ -46,7 +47,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("disable token", func(t *testing.T) {
-		tokenID, err := tokenSvc.GenerateToken(ctx)
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
 		require.NoError(t, err)
 
 		// disable token
@@ -68,11 +69,15 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("redeem token", func(t *testing.T) {
-		tokenID, err := tokenSvc.GenerateToken(ctx)
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
 		require.NoError(t, err)
 
 		// redeem token
-		err = tokenSvc.RedeemToken(ctx, tokenID)
+		err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{
+			UserID:    "user-1",
+			Email:     "user@example.com",
+			IPAddress: "127.0.0.1",
+		})
 		require.NoError(t, err)
 
 		// verify token is redeemed
@@ -80,18 +85,591 @@ func TestService(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, gotTk.RedeemedAt)
 
+		// verify redemption history
+		redemptions, err := tokenSvc.ListRedemptions(ctx, tokenID)
+		require.NoError(t, err)
+		require.Len(t, redemptions, 1)
+		assert.Equal(t, "user-1", redemptions[0].UserID)
+		assert.Equal(t, "user@example.com", redemptions[0].Email)
+		assert.Equal(t, "127.0.0.1", redemptions[0].IPAddress)
+
 		t.Run("token not found", func(t *testing.T) {
 			tokenID, err = token.NewID()
 			require.NoError(t, err)
 
-			err = tokenSvc.RedeemToken(ctx, tokenID)
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+
+			_, err = tokenSvc.ListRedemptions(ctx, tokenID)
 			assert.ErrorIs(t, err, token.ErrTokenNotFound)
 		})
+
+		t.Run("invalid redemption", func(t *testing.T) {
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+			require.NoError(t, err)
+
+			for _, params := range []token.RedeemParams{
+				{UserID: strings.Repeat("a", token.MaxUserIDLen+1)},
+				{Email: strings.Repeat("a", token.MaxEmailLen+1)},
+				{Metadata: map[string]string{"a": strings.Repeat("a", token.MaxMetadataSize)}},
+			} {
+				err = tokenSvc.RedeemToken(ctx, tokenID, params)
+				assert.ErrorIs(t, err, token.ErrInvalidRedemption)
+			}
+
+			// long user agents are truncated
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{
+				UserAgent: strings.Repeat("é", 1000),
+			})
+			require.NoError(t, err)
+
+			redemptions, err := tokenSvc.ListRedemptions(ctx, tokenID)
+			require.NoError(t, err)
+			require.Len(t, redemptions, 1)
+			assert.Less(t, len(redemptions[0].UserAgent), 1000)
+			assert.True(t, utf8.ValidString(redemptions[0].UserAgent))
+		})
+	})
+
+	t.Run("redeem multi-use token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			MaxRedemptions: 2,
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			require.NoError(t, err)
+		}
+
+		err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+		assert.ErrorIs(t, err, token.ErrTokenExhausted)
+
+		t.Run("invalid max redemptions", func(t *testing.T) {
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				MaxRedemptions: -1,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidMaxRedemptions)
+		})
+	})
+
+	t.Run("generate token with expiration", func(t *testing.T) {
+		// default ttl
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(token.DefaultTTL), *gotTk.ExpiresAt, time.Minute)
+
+		// custom ttl
+		tokenID, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			TTL: time.Hour,
+		})
+		require.NoError(t, err)
+
+		gotTk, err = tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *gotTk.ExpiresAt, time.Minute)
+
+		// never expires
+		tokenID, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			NeverExpires: true,
+		})
+		require.NoError(t, err)
+
+		gotTk, err = tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Nil(t, gotTk.ExpiresAt)
+		assert.False(t, gotTk.Expired())
+
+		t.Run("invalid expiration", func(t *testing.T) {
+			past := time.Now().Add(-time.Hour)
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				ExpiresAt: &past,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidExpiration)
+
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				TTL: time.Hour, NeverExpires: true,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidExpiration)
+		})
+	})
+
+	t.Run("generate tokens", func(t *testing.T) {
+		tokenIDs, err := tokenSvc.GenerateTokens(ctx, 10, token.GenerateParams{
+			MaxRedemptions: 2,
+		})
+		require.NoError(t, err)
+		assert.Len(t, tokenIDs, 10)
+
+		for _, tokenID := range tokenIDs {
+			gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, gotTk.MaxRedemptions)
+		}
+
+		t.Run("invalid count", func(t *testing.T) {
+			_, err = tokenSvc.GenerateTokens(ctx, 0, token.GenerateParams{})
+			assert.ErrorIs(t, err, token.ErrInvalidCount)
+
+			_, err = tokenSvc.GenerateTokens(ctx, token.MaxBatchSize+1, token.GenerateParams{})
+			assert.ErrorIs(t, err, token.ErrInvalidCount)
+		})
+	})
+
+	t.Run("generate token with metadata and labels", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			Metadata: map[string]interface{}{"campaign": "spring launch"},
+			Labels:   []string{" launch ", "launch", "vip"},
+		})
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"campaign": "spring launch"}, gotTk.Metadata)
+		assert.Equal(t, []string{"launch", "vip"}, gotTk.Labels)
+
+		page, err := tokenSvc.ListTokens(ctx, token.ListQuery{Labels: []string{"vip"}})
+		require.NoError(t, err)
+		require.Len(t, page.Tokens, 1)
+		assert.Equal(t, tokenID, page.Tokens[0].ID)
+
+		t.Run("invalid labels", func(t *testing.T) {
+			_, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Labels: []string{""},
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidLabels)
+
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Labels: []string{strings.Repeat("a", 65)},
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidLabels)
+		})
+
+		t.Run("invalid metadata", func(t *testing.T) {
+			_, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Metadata: map[string]interface{}{
+					"notes": strings.Repeat("a", token.MaxMetadataSize),
+				},
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidMetadata)
+		})
 	})
 
 	t.Run("list tokens", func(t *testing.T) {
-		tokens, err := tokenSvc.ListTokens(ctx)
+		page, err := tokenSvc.ListTokens(ctx, token.ListQuery{})
+		require.NoError(t, err)
+		assert.Len(t, page.Tokens, 19)
+		assert.Nil(t, page.Next)
+
+		t.Run("paginate", func(t *testing.T) {
+			seen := make(map[token.ID]struct{})
+			query := token.ListQuery{Limit: 5, Sort: token.SortDesc}
+			for {
+				page, err := tokenSvc.ListTokens(ctx, query)
+				require.NoError(t, err)
+
+				for _, tk := range page.Tokens {
+					seen[tk.ID] = struct{}{}
+				}
+
+				if page.Next == nil {
+					break
+				}
+				query.After = page.Next
+			}
+
+			assert.Len(t, seen, 19)
+		})
+
+		t.Run("filter by status", func(t *testing.T) {
+			page, err := tokenSvc.ListTokens(ctx, token.ListQuery{
+				Status: token.StatusDisabled,
+			})
+			require.NoError(t, err)
+			assert.Len(t, page.Tokens, 1)
+
+			page, err = tokenSvc.ListTokens(ctx, token.ListQuery{
+				Status: token.StatusRedeemed,
+			})
+			require.NoError(t, err)
+			assert.Len(t, page.Tokens, 3)
+		})
+
+		t.Run("summarize tokens", func(t *testing.T) {
+			summary, err := tokenSvc.SummarizeTokens(ctx)
+			require.NoError(t, err)
+			assert.Len(t, summary, len(token.Statuses))
+			assert.Equal(t, 1, summary[token.StatusDisabled])
+			assert.Equal(t, 3, summary[token.StatusRedeemed])
+			assert.Equal(t, 0, summary[token.StatusExpired])
+			assert.Equal(t, 15, summary[token.StatusActive])
+		})
+
+		t.Run("invalid query", func(t *testing.T) {
+			_, err := tokenSvc.ListTokens(ctx, token.ListQuery{Status: "unknown"})
+			assert.ErrorIs(t, err, token.ErrInvalidListQuery)
+
+			_, err = tokenSvc.ListTokens(ctx, token.ListQuery{Sort: "random"})
+			assert.ErrorIs(t, err, token.ErrInvalidListQuery)
+
+			_, err = tokenSvc.ListTokens(ctx, token.ListQuery{Limit: token.MaxListLimit + 1})
+			assert.ErrorIs(t, err, token.ErrInvalidListQuery)
+		})
+	})
+
+	t.Run("enable token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		// token is not disabled
+		err = tokenSvc.EnableToken(ctx, tokenID)
+		assert.ErrorIs(t, err, token.ErrTokenEnabled)
+
+		err = tokenSvc.DisableToken(ctx, tokenID)
+		require.NoError(t, err)
+
+		err = tokenSvc.EnableToken(ctx, tokenID)
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.False(t, gotTk.Disabled)
+
+		t.Run("token redeemed", func(t *testing.T) {
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			require.NoError(t, err)
+
+			err = tokenSvc.DisableToken(ctx, tokenID)
+			require.NoError(t, err)
+
+			err = tokenSvc.EnableToken(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenRedeemed)
+		})
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err = token.NewID()
+			require.NoError(t, err)
+
+			err = tokenSvc.EnableToken(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("extend token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			TTL: time.Hour,
+		})
+		require.NoError(t, err)
+
+		err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{
+			TTL: 48 * time.Hour,
+		})
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
 		require.NoError(t, err)
-		assert.Len(t, tokens, 3)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), *gotTk.ExpiresAt, time.Minute)
+
+		t.Run("invalid expiration", func(t *testing.T) {
+			// earlier than the current expiration
+			err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{
+				TTL: time.Hour,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidExpiration)
+
+			// nothing set
+			err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{})
+			assert.ErrorIs(t, err, token.ErrInvalidExpiration)
+		})
+
+		t.Run("never expires", func(t *testing.T) {
+			err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{
+				NeverExpires: true,
+			})
+			require.NoError(t, err)
+
+			gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+			require.NoError(t, err)
+			assert.Nil(t, gotTk.ExpiresAt)
+		})
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err = token.NewID()
+			require.NoError(t, err)
+
+			err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{
+				TTL: time.Hour,
+			})
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("revoke token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		err = tokenSvc.RevokeToken(ctx, tokenID, " ")
+		assert.ErrorIs(t, err, token.ErrInvalidRevokeReason)
+
+		err = tokenSvc.RevokeToken(ctx, tokenID, "leaked")
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Equal(t, token.StatusRevoked, gotTk.Status())
+		assert.Equal(t, "leaked", gotTk.RevokeReason)
+
+		// revoked tokens cannot be redeemed, revoked,
+		// re-enabled or extended anymore
+		err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+		assert.ErrorIs(t, err, token.ErrTokenRevoked)
+
+		err = tokenSvc.RevokeToken(ctx, tokenID, "leaked")
+		assert.ErrorIs(t, err, token.ErrTokenRevoked)
+
+		err = tokenSvc.EnableToken(ctx, tokenID)
+		assert.ErrorIs(t, err, token.ErrTokenRevoked)
+
+		err = tokenSvc.ExtendToken(ctx, tokenID, token.ExtendParams{
+			NeverExpires: true,
+		})
+		assert.ErrorIs(t, err, token.ErrTokenRevoked)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err = token.NewID()
+			require.NoError(t, err)
+
+			err = tokenSvc.RevokeToken(ctx, tokenID, "leaked")
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
+	})
+
+	t.Run("campaigns", func(t *testing.T) {
+		endsAt := time.Now().Add(24 * time.Hour)
+		campaign, err := tokenSvc.CreateCampaign(ctx, token.CampaignParams{
+			Name:           " Spring launch ",
+			TTL:            time.Hour,
+			MaxRedemptions: 5,
+			EndsAt:         &endsAt,
+			MaxTokens:      3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Spring launch", campaign.Name)
+		assert.True(t, campaign.Active())
+
+		// tokens inherit the campaign settings
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			Campaign: campaign.ID,
+		})
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		assert.Equal(t, campaign.ID, gotTk.CampaignID)
+		assert.Equal(t, 5, gotTk.MaxRedemptions)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *gotTk.ExpiresAt, time.Minute)
+
+		// campaign tokens expire no later than the campaign
+		tokenID2, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			Campaign:     campaign.ID,
+			NeverExpires: true,
+		})
+		require.NoError(t, err)
+
+		gotTk, err = tokenSvc.GetToken(ctx, tokenID2)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.ExpiresAt)
+		assert.WithinDuration(t, endsAt, *gotTk.ExpiresAt, time.Second)
+
+		page, err := tokenSvc.ListTokens(ctx, token.ListQuery{Campaign: campaign.ID})
+		require.NoError(t, err)
+		assert.Len(t, page.Tokens, 2)
+
+		t.Run("token limit", func(t *testing.T) {
+			_, err := tokenSvc.GenerateTokens(ctx, 2, token.GenerateParams{
+				Campaign: campaign.ID,
+			})
+			assert.ErrorIs(t, err, token.ErrCampaignFull)
+
+			campaign, err = tokenSvc.GetCampaign(ctx, campaign.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, campaign.Tokens)
+
+			// the limit cannot be lower than the number of tokens
+			_, err = tokenSvc.UpdateCampaign(ctx, campaign.ID, token.CampaignParams{
+				Name:      campaign.Name,
+				MaxTokens: 1,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidCampaign)
+		})
+
+		t.Run("disable campaign", func(t *testing.T) {
+			err := tokenSvc.DisableCampaign(ctx, campaign.ID)
+			require.NoError(t, err)
+
+			gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+			require.NoError(t, err)
+			assert.Equal(t, token.StatusDisabled, gotTk.Status())
+
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			assert.ErrorIs(t, err, token.ErrCampaignDisabled)
+
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Campaign: campaign.ID,
+			})
+			assert.ErrorIs(t, err, token.ErrCampaignDisabled)
+
+			err = tokenSvc.EnableCampaign(ctx, campaign.ID)
+			require.NoError(t, err)
+
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			assert.NoError(t, err)
+		})
+
+		t.Run("update campaign", func(t *testing.T) {
+			startsAt := time.Now().Add(time.Hour)
+			updated, err := tokenSvc.UpdateCampaign(ctx, campaign.ID, token.CampaignParams{
+				Name:     "Summer launch",
+				StartsAt: &startsAt,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "Summer launch", updated.Name)
+			assert.False(t, updated.Active())
+
+			// tokens can be generated before the campaign starts
+			// but cannot be redeemed until the campaign starts
+			tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Campaign: campaign.ID,
+			})
+			require.NoError(t, err)
+
+			gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+			require.NoError(t, err)
+			require.NotNil(t, gotTk.NotBefore)
+			assert.WithinDuration(t, startsAt, *gotTk.NotBefore, time.Second)
+			assert.Equal(t, token.StatusPending, gotTk.Status())
+
+			err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+			assert.ErrorIs(t, err, token.ErrTokenNotYetValid)
+
+			endsAt := time.Now().Add(-time.Minute)
+			_, err = tokenSvc.UpdateCampaign(ctx, campaign.ID, token.CampaignParams{
+				Name:   "Summer launch",
+				EndsAt: &endsAt,
+			})
+			require.NoError(t, err)
+
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Campaign: campaign.ID,
+			})
+			assert.ErrorIs(t, err, token.ErrCampaignEnded)
+		})
+
+		t.Run("list campaigns", func(t *testing.T) {
+			campaigns, err := tokenSvc.ListCampaigns(ctx)
+			require.NoError(t, err)
+			require.Len(t, campaigns, 1)
+			assert.Equal(t, campaign.ID, campaigns[0].ID)
+		})
+
+		t.Run("delete campaign", func(t *testing.T) {
+			err := tokenSvc.DeleteCampaign(ctx, campaign.ID)
+			assert.ErrorIs(t, err, token.ErrCampaignHasTokens)
+
+			empty, err := tokenSvc.CreateCampaign(ctx, token.CampaignParams{
+				Name: "Empty",
+			})
+			require.NoError(t, err)
+
+			err = tokenSvc.DeleteCampaign(ctx, empty.ID)
+			require.NoError(t, err)
+
+			_, err = tokenSvc.GetCampaign(ctx, empty.ID)
+			assert.ErrorIs(t, err, token.ErrCampaignNotFound)
+		})
+
+		t.Run("invalid campaign", func(t *testing.T) {
+			_, err := tokenSvc.CreateCampaign(ctx, token.CampaignParams{})
+			assert.ErrorIs(t, err, token.ErrInvalidCampaign)
+
+			startsAt := time.Now()
+			_, err = tokenSvc.CreateCampaign(ctx, token.CampaignParams{
+				Name:     "Backwards",
+				StartsAt: &startsAt,
+				EndsAt:   &endsAt,
+				TTL:      -time.Hour,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidCampaign)
+
+			_, err = tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				Campaign: token.NewCampaignID(),
+			})
+			assert.ErrorIs(t, err, token.ErrCampaignNotFound)
+		})
+	})
+
+	t.Run("generate token with not before", func(t *testing.T) {
+		notBefore := time.Now().Add(time.Hour)
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+			NotBefore: &notBefore,
+		})
+		require.NoError(t, err)
+
+		gotTk, err := tokenSvc.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, gotTk.NotBefore)
+		assert.WithinDuration(t, notBefore, *gotTk.NotBefore, time.Second)
+		assert.Equal(t, token.StatusPending, gotTk.Status())
+
+		err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+		assert.ErrorIs(t, err, token.ErrTokenNotYetValid)
+
+		page, err := tokenSvc.ListTokens(ctx, token.ListQuery{Status: token.StatusPending})
+		require.NoError(t, err)
+		assert.NotEmpty(t, page.Tokens)
+		for _, tk := range page.Tokens {
+			assert.Equal(t, token.StatusPending, tk.Status())
+		}
+
+		t.Run("invalid not before", func(t *testing.T) {
+			notBefore := time.Now().Add(2 * time.Hour)
+			_, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{
+				TTL:       time.Hour,
+				NotBefore: &notBefore,
+			})
+			assert.ErrorIs(t, err, token.ErrInvalidNotBefore)
+		})
+	})
+
+	t.Run("validate token", func(t *testing.T) {
+		tokenID, err := tokenSvc.GenerateToken(ctx, token.GenerateParams{})
+		require.NoError(t, err)
+
+		// validation does not consume the token
+		for i := 0; i < 2; i++ {
+			err = tokenSvc.ValidateToken(ctx, tokenID)
+			assert.NoError(t, err)
+		}
+
+		err = tokenSvc.RedeemToken(ctx, tokenID, token.RedeemParams{})
+		require.NoError(t, err)
+
+		err = tokenSvc.ValidateToken(ctx, tokenID)
+		assert.ErrorIs(t, err, token.ErrTokenRedeemed)
+
+		t.Run("token not found", func(t *testing.T) {
+			tokenID, err := token.NewID()
+			require.NoError(t, err)
+
+			err = tokenSvc.ValidateToken(ctx, tokenID)
+			assert.ErrorIs(t, err, token.ErrTokenNotFound)
+		})
 	})
 }
@@ -1,6 +1,7 @@
 package token
 
 import (
+	"strings"
 	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
@@ -12,8 +13,10 @@ const (
 	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 	// idLen is the len of token id
 	idLen = 12
-	// tokenExpirDur is the token expiration duration
-	tokenExpirDur = time.Hour * 24 * 7
+	// DefaultTTL is the default token time-to-live
+	DefaultTTL = time.Hour * 24 * 7
+	// MaxBatchSize is the max number of tokens generated in one batch
+	MaxBatchSize = 10000
 )
 
 // ID is a invite token id
@@ -33,49 +36,214 @@ func NewID() (ID, error) {
 	return ID(id), nil
 }
 
+// WellFormed returns true if the id has the length and alphabet of generated ids
+func (id ID) WellFormed() bool {
+	if len(id) != idLen {
+		return false
+	}
+
+	for _, r := range id {
+		if !strings.ContainsRune(alphabet, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newUniqueIDs returns n unique invite token ids
+func newUniqueIDs(n int) ([]ID, error) {
+	seen := make(map[ID]struct{}, n)
+	ids := make([]ID, 0, n)
+	for len(ids) < n {
+		id, err := NewID()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Status is a token status
+type Status string
+
+// List of token statuses
+const (
+	// StatusActive is the status of a token that can be redeemed
+	StatusActive Status = "active"
+	// StatusRedeemed is the status of a token that has no remaining redemptions
+	StatusRedeemed Status = "redeemed"
+	// StatusDisabled is the status of a disabled token
+	StatusDisabled Status = "disabled"
+	// StatusExpired is the status of an expired token
+	StatusExpired Status = "expired"
+	// StatusRevoked is the status of a revoked token
+	StatusRevoked Status = "revoked"
+	// StatusPending is the status of a token that cannot be redeemed yet
+	StatusPending Status = "pending"
+)
+
+// Statuses is the list of token statuses
+var Statuses = []Status{StatusActive, StatusRedeemed,
+	StatusDisabled, StatusExpired, StatusRevoked, StatusPending}
+
+// Valid returns true if the status is a known status
+func (s Status) Valid() bool {
+	switch s {
+	case StatusActive, StatusRedeemed, StatusDisabled,
+		StatusExpired, StatusRevoked, StatusPending:
+		return true
+	}
+
+	return false
+}
+
 // Token is an invite token
 type Token struct {
 	// ID is the token string
 	ID ID `json:"id"`
 	// Disabled is true when token is recalled/disabled
 	Disabled bool `json:"disabled"`
-	// RedeemedAt is the redeem timestamp
+	// MaxRedemptions is the number of times the token can be redeemed
+	MaxRedemptions int `json:"maxRedemptions"`
+	// Redemptions is the number of times the token was redeemed
+	Redemptions int `json:"redemptions"`
+	// ExpiresAt is the expiration timestamp, nil if never expires
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// NotBefore is the timestamp the token can be redeemed
+	// from, nil if the token can be redeemed on creation
+	NotBefore *time.Time `json:"notBefore"`
+	// RevokedAt is the revoke timestamp, nil if not revoked
+	RevokedAt *time.Time `json:"revokedAt"`
+	// RevokeReason is the reason the token was revoked
+	RevokeReason string `json:"revokeReason"`
+	// Metadata is free-form metadata, e.g. the campaign or recipient
+	Metadata map[string]interface{} `json:"metadata"`
+	// Labels is the list of labels used for filtering tokens
+	Labels []string `json:"labels"`
+	// CampaignID is the id of the campaign, empty if none
+	CampaignID CampaignID `json:"campaignId"`
+	// CampaignDisabled is true when the campaign of the token is disabled
+	CampaignDisabled bool `json:"campaignDisabled"`
+	// RedeemedAt is the last redeem timestamp
 	RedeemedAt *time.Time `json:"redeemedAt"`
 	// CreatedAt is the created at timestamp
 	CreatedAt *time.Time `json:"createdAt"`
 }
 
-// Expiration returns the token expiration
-func (t *Token) Expiration() time.Time {
-	return t.CreatedAt.Add(tokenExpirDur)
-}
-
-// RedeemedAt returns true if the token is redeemed
+// Redeemed returns true if the token was redeemed at least once
 func (t *Token) Redeemed() bool {
 	return t.RedeemedAt != nil
 }
 
+// Revoked returns true if the token was revoked
+func (t *Token) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// Remaining returns the number of remaining redemptions
+func (t *Token) Remaining() int {
+	if t.Redemptions >= t.MaxRedemptions {
+		return 0
+	}
+
+	return t.MaxRedemptions - t.Redemptions
+}
+
+// Exhausted returns true if the token has no remaining redemptions
+func (t *Token) Exhausted() bool {
+	return t.Remaining() == 0
+}
+
 // Expired returns true if the token is expired
 func (t *Token) Expired() bool {
-	return time.Now().After(t.Expiration())
+	// token never expires
+	if t.ExpiresAt == nil {
+		return false
+	}
+
+	return time.Now().After(*t.ExpiresAt)
+}
+
+// NotYetValid returns true if the token cannot be redeemed yet
+func (t *Token) NotYetValid() bool {
+	return t.NotBefore != nil && time.Now().Before(*t.NotBefore)
+}
+
+// Status returns the token status, statuses are checked
+// in the same order as the rules in Validate
+func (t *Token) Status() Status {
+	switch {
+	case t.Revoked():
+		return StatusRevoked
+	case t.Disabled, t.CampaignDisabled:
+		return StatusDisabled
+	case t.Exhausted():
+		return StatusRedeemed
+	case t.Expired():
+		return StatusExpired
+	case t.NotYetValid():
+		return StatusPending
+	}
+
+	return StatusActive
 }
 
 // Validate token if possible to redeem
 func (t *Token) Validate() error {
+	// check if revoked
+	if t.Revoked() {
+		return ErrTokenRevoked
+	}
+
 	// check if disabled
 	if t.Disabled {
 		return ErrTokenDisabled
 	}
 
+	// check if campaign is disabled
+	if t.CampaignDisabled {
+		return ErrCampaignDisabled
+	}
+
+	// check if redeemed already, a used up token
+	// stays redeemed after it expires
+	if err := t.validateRemaining(); err != nil {
+		return err
+	}
+
 	// check if expired
 	if t.Expired() {
 		return ErrTokenExpired
 	}
 
-	// check if redeemed already
-	if t.Redeemed() {
-		return ErrTokenRedeemed
+	// check if redemption window is open
+	if t.NotYetValid() {
+		return ErrTokenNotYetValid
 	}
 
 	return nil
 }
+
+// validateRemaining returns an error if the
+// token has no remaining redemptions
+func (t *Token) validateRemaining() error {
+	if !t.Exhausted() {
+		return nil
+	}
+
+	// single-use tokens are simply redeemed
+	if t.MaxRedemptions > 1 {
+		return ErrTokenExhausted
+	}
+
+	return ErrTokenRedeemed
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -16,15 +17,150 @@ func TestToken(t *testing.T) {
 	assert.NotEmpty(t, tokenID)
 
 	createdAt := time.Date(2021, time.August, 13, 11, 0, 0, 0, time.Local)
-	token := &token.Token{
-		ID:         tokenID,
-		CreatedAt:  &createdAt,
-		RedeemedAt: &createdAt,
+	expiresAt := createdAt.Add(token.DefaultTTL)
+	tk := &token.Token{
+		ID:             tokenID,
+		MaxRedemptions: 1,
+		Redemptions:    1,
+		ExpiresAt:      &expiresAt,
+		CreatedAt:      &createdAt,
+		RedeemedAt:     &createdAt,
 	}
 
-	expiration := createdAt.Add(time.Hour * 24 * 7)
-	assert.Equal(t, token.Expiration(), expiration)
-	assert.True(t, token.Expired())
-	assert.True(t, token.Redeemed())
-	assert.Error(t, token.Validate())
+	assert.True(t, tk.Expired())
+	assert.True(t, tk.Redeemed())
+	assert.True(t, tk.Exhausted())
+	// a token that was redeemed and then expired stays redeemed
+	assert.ErrorIs(t, tk.Validate(), token.ErrTokenRedeemed)
+	assert.Equal(t, token.StatusRedeemed, tk.Status())
+	reason, ok := token.ReasonOf(tk.Validate())
+	assert.True(t, ok)
+	assert.Equal(t, token.ReasonRedeemed, reason)
+
+	t.Run("multi-use token", func(t *testing.T) {
+		now := time.Now()
+		tk := &token.Token{
+			ID:             tokenID,
+			MaxRedemptions: 3,
+			Redemptions:    2,
+			CreatedAt:      &now,
+			RedeemedAt:     &now,
+		}
+
+		assert.Equal(t, 1, tk.Remaining())
+		assert.False(t, tk.Exhausted())
+		assert.NoError(t, tk.Validate())
+
+		tk.Redemptions = 3
+		assert.Equal(t, 0, tk.Remaining())
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenExhausted)
+
+		tk.MaxRedemptions, tk.Redemptions = 1, 1
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenRedeemed)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		tk := &token.Token{
+			ID:             tokenID,
+			MaxRedemptions: 2,
+			ExpiresAt:      &future,
+			CreatedAt:      &createdAt,
+		}
+		assert.Equal(t, token.StatusActive, tk.Status())
+
+		tk.Redemptions = 2
+		assert.Equal(t, token.StatusRedeemed, tk.Status())
+
+		tk.ExpiresAt = &createdAt
+		assert.Equal(t, token.StatusRedeemed, tk.Status())
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenExhausted)
+
+		tk.Redemptions = 1
+		assert.Equal(t, token.StatusExpired, tk.Status())
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenExpired)
+
+		tk.Disabled = true
+		assert.Equal(t, token.StatusDisabled, tk.Status())
+
+		tk.RevokedAt = &createdAt
+		assert.Equal(t, token.StatusRevoked, tk.Status())
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenRevoked)
+
+		for _, status := range token.Statuses {
+			assert.True(t, status.Valid())
+		}
+		assert.False(t, token.Status("unknown").Valid())
+	})
+
+	t.Run("never expires", func(t *testing.T) {
+		tk := &token.Token{
+			ID:             tokenID,
+			MaxRedemptions: 1,
+			CreatedAt:      &createdAt,
+		}
+
+		assert.False(t, tk.Expired())
+		assert.NoError(t, tk.Validate())
+	})
+
+	t.Run("campaign disabled", func(t *testing.T) {
+		tk := &token.Token{
+			ID:             tokenID,
+			MaxRedemptions: 1,
+			CampaignID:     token.NewCampaignID(),
+			CreatedAt:      &createdAt,
+		}
+		assert.Equal(t, token.StatusActive, tk.Status())
+
+		tk.CampaignDisabled = true
+		assert.Equal(t, token.StatusDisabled, tk.Status())
+		assert.ErrorIs(t, tk.Validate(), token.ErrCampaignDisabled)
+	})
+
+	t.Run("not yet valid", func(t *testing.T) {
+		notBefore := time.Now().Add(time.Hour)
+		tk := &token.Token{
+			ID:             tokenID,
+			MaxRedemptions: 1,
+			NotBefore:      &notBefore,
+			CreatedAt:      &createdAt,
+		}
+		assert.True(t, tk.NotYetValid())
+		assert.Equal(t, token.StatusPending, tk.Status())
+		assert.ErrorIs(t, tk.Validate(), token.ErrTokenNotYetValid)
+
+		notBefore = time.Now().Add(-time.Hour)
+		assert.False(t, tk.NotYetValid())
+		assert.Equal(t, token.StatusActive, tk.Status())
+		assert.NoError(t, tk.Validate())
+	})
+}
+
+func TestReasonOf(t *testing.T) {
+	reason, ok := token.ReasonOf(nil)
+	assert.True(t, ok)
+	assert.Equal(t, token.ReasonValid, reason)
+
+	reason, ok = token.ReasonOf(errors.Wrap(token.ErrTokenNotFound, "get token"))
+	assert.True(t, ok)
+	assert.Equal(t, token.ReasonNotFound, reason)
+
+	reason, ok = token.ReasonOf(token.ErrCampaignDisabled)
+	assert.True(t, ok)
+	assert.Equal(t, token.ReasonDisabled, reason)
+
+	_, ok = token.ReasonOf(errors.New("connection refused"))
+	assert.False(t, ok)
+}
+
+func TestIDWellFormed(t *testing.T) {
+	id, err := token.NewID()
+	require.NoError(t, err)
+	assert.True(t, id.WellFormed())
+
+	assert.False(t, id[:len(id)-1].WellFormed())
+	assert.False(t, (id + "A").WellFormed())
+	assert.False(t, token.ID("abc-def_ghij").WellFormed())
+	assert.False(t, token.NilID.WellFormed())
 }